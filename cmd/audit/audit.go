@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/analysis"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/output"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the rules of Security Groups.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := cmdutils.ValidateOutputFormat(outputFormat); err != nil {
+				return err
+			}
+
+			if err := analysis.ValidateSeverity(minSeverity); err != nil {
+				return err
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			return err
+		},
+		RunE: runAudit,
+	}
+
+	sg                       *[]string
+	scope                    core.Scope
+	outputFormat             string
+	minSeverity              string
+	sensitivePorts           []int32
+	forbidUnrestrictedEgress bool
+	filters                  core.Filters
+	filterFlags              cmdutils.FilterFlags
+)
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	groups, err := core.ListSecurityGroupsInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	findings := make([]coreTypes.AuditFinding, 0)
+	for _, finding := range analysis.Audit(groups, analysis.AuditOptions{
+		SensitivePorts:           sensitivePorts,
+		ForbidUnrestrictedEgress: forbidUnrestrictedEgress,
+	}) {
+		if analysis.IsAtLeast(finding.Severity, minSeverity) {
+			findings = append(findings, finding)
+		}
+	}
+
+	if outputFormat == cmdutils.OutputJSON {
+		return output.WriteJSON(os.Stdout, output.NewAuditDocument(findings))
+	}
+
+	printFindings(findings)
+	return nil
+}
+
+func printFindings(findings []coreTypes.AuditFinding) {
+	pterm.DefaultSection.Println("Audit findings")
+
+	if len(findings) == 0 {
+		pterm.Info.Println("No risky Security Group rule found.")
+		return
+	}
+
+	bulletList := make([]pterm.BulletListItem, 0)
+	for _, finding := range findings {
+		subject := fmt.Sprintf("%s (%s)", finding.SecurityGroupName, pterm.LightGreen(finding.SecurityGroupId))
+		if finding.RuleId != "" {
+			subject = fmt.Sprintf("%s rule %s", subject, pterm.LightGreen(finding.RuleId))
+		}
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text: fmt.Sprintf("[%s] %s [%s]: %s", cmdutils.GetSeverityText(finding.Severity), subject,
+				pterm.Cyan(cmdutils.GetLocationText(finding.AccountId, finding.Region)), finding.Message),
+		})
+
+		if !finding.InUse {
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       1,
+				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+				Text:        "Security Group is not in use",
+			})
+			continue
+		}
+
+		if len(finding.NetworkInterfaces) > 0 {
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       1,
+				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+				Text: fmt.Sprintf("Used by Network Interface(s): %s",
+					pterm.LightBlue(strings.Join(finding.NetworkInterfaces, ", "))),
+			})
+		}
+		for _, resource := range finding.Resources {
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       1,
+				TextStyle:   pterm.NewStyle(pterm.FgCyan),
+				BulletStyle: pterm.NewStyle(pterm.FgCyan),
+				Text:        resource,
+			})
+		}
+	}
+
+	_ = pterm.DefaultBulletList.WithItems(bulletList).Render()
+}
+
+func init() {
+	includeValidateFlags(Cmd)
+}
+
+func includeValidateFlags(cmd *cobra.Command) {
+	sg = cmd.Flags().StringSlice("sg", nil,
+		"[Optional] Security Group Id to be audited. It can accept multiple values divided by comma. "+
+			"Default: none (if none is specified all security groups will be audited)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", cmdutils.OutputText,
+		"[Optional] Output format. Accepted values: text, json.")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", coreTypes.SeverityLow,
+		"[Optional] Lowest severity of the findings to be reported. Accepted values: critical, high, medium, low.")
+	cmd.Flags().Int32SliceVar(&sensitivePorts, "sensitive-ports", nil,
+		"[Optional] Ports which must not be open to the internet. It can accept multiple values divided by comma. "+
+			"Default: the ports of common remote administration, file sharing, database and cache services")
+	cmd.Flags().BoolVar(&forbidUnrestrictedEgress, "forbid-unrestricted-egress", false,
+		"[Optional] Report the outbound rules allowing traffic to the internet.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+}
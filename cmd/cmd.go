@@ -2,10 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/audit"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/cmd/dedupe"
+	"github.com/cloud-crafts/sg-ripper/cmd/graph"
 	"github.com/cloud-crafts/sg-ripper/cmd/list"
 	"github.com/cloud-crafts/sg-ripper/cmd/listeni"
+	"github.com/cloud-crafts/sg-ripper/cmd/redundantrules"
 	"github.com/cloud-crafts/sg-ripper/cmd/remove"
 	"github.com/cloud-crafts/sg-ripper/cmd/removeeni"
+	"github.com/cloud-crafts/sg-ripper/cmd/restore"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"os"
@@ -29,8 +36,15 @@ var (
 		TraverseChildren: true,
 	}
 
-	region  string
-	profile string
+	region        string
+	regions       []string
+	allRegions    bool
+	accounts      []string
+	organization  bool
+	roleName      string
+	protectionTag string
+	keepList      string
+	profile       string
 )
 
 func init() {
@@ -39,6 +53,11 @@ func init() {
 	rootCmd.AddCommand(listeni.Cmd)
 	rootCmd.AddCommand(remove.Cmd)
 	rootCmd.AddCommand(removeeni.Cmd)
+	rootCmd.AddCommand(restore.Cmd)
+	rootCmd.AddCommand(graph.Cmd)
+	rootCmd.AddCommand(audit.Cmd)
+	rootCmd.AddCommand(dedupe.Cmd)
+	rootCmd.AddCommand(redundantrules.Cmd)
 
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
@@ -46,6 +65,24 @@ func init() {
 func includeValidateFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&region, "region", "",
 		"[Optional] AWS Region.")
+	cmd.PersistentFlags().StringSliceVar(&regions, "regions", nil,
+		"[Optional] AWS Regions to be scanned concurrently. It can accept multiple values divided by comma.")
+	cmd.PersistentFlags().BoolVar(&allRegions, "all-regions", false,
+		"[Optional] Scan every region enabled for the account. It cannot be used together with --region or --regions.")
+	cmd.PersistentFlags().StringSliceVar(&accounts, "accounts", nil,
+		"[Optional] AWS Account IDs to be scanned by assuming the role provided with --role-name. It can accept "+
+			"multiple values divided by comma.")
+	cmd.PersistentFlags().BoolVar(&organization, "organization", false,
+		"[Optional] Scan every active member account of the AWS Organization by assuming the role provided with "+
+			"--role-name. The profile has to belong to the management account or to a delegated administrator.")
+	cmd.PersistentFlags().StringVar(&roleName, "role-name", "",
+		"[Optional] Name of the IAM role assumed in every scanned account other than the account of the profile.")
+	cmd.PersistentFlags().StringVar(&protectionTag, "protection-tag", core.DefaultProtectionTag,
+		"[Optional] Tag protecting Security Groups and Network Interfaces from removal, in the form of key=value or "+
+			"key. An empty value disables the protection by tag.")
+	cmd.PersistentFlags().StringVar(&keepList, "keep-list", cmdutils.DefaultKeepListFile(),
+		"[Optional] File with the IDs of the Security Groups and Network Interfaces protected from removal, one per "+
+			"line. The default file is ignored if it does not exist.")
 	cmd.PersistentFlags().StringVar(&profile, "profile", "",
 		"[Optional] Profile.")
 }
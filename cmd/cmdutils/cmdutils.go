@@ -1,6 +1,17 @@
 package cmdutils
 
-import "github.com/pterm/pterm"
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"strings"
+)
 
 func GetENIStatusColor(status string) string {
 	var stylized string
@@ -22,3 +33,335 @@ func GetENIManagedByAWSText(managedByAWS bool) string {
 	}
 	return pterm.LightGreen("NO")
 }
+
+// GetConfigurationReferenceText returns the kind and the name of a configuration referencing a Security Group, together
+// with its version and its alias if it has them
+func GetConfigurationReferenceText(reference coreTypes.ConfigurationReference) string {
+	switch reference.Type {
+	case coreTypes.LaunchTemplateReference:
+		return fmt.Sprintf("Launch template %s (%s) version %s", reference.Name, reference.Id, reference.Version)
+	case coreTypes.LaunchConfigurationReference:
+		return fmt.Sprintf("Launch configuration %s", reference.Name)
+	case coreTypes.AutoScalingGroupReference:
+		return fmt.Sprintf("Auto Scaling group %s", reference.Name)
+	case coreTypes.EcsServiceReference:
+		return fmt.Sprintf("ECS service %s (%s)", reference.Name, reference.Id)
+	case coreTypes.EcsScheduledTaskReference:
+		return fmt.Sprintf("ECS task scheduled by the EventBridge rule %s", reference.Name)
+	case coreTypes.EcsScheduleReference:
+		return fmt.Sprintf("ECS task scheduled by the EventBridge Scheduler schedule %s", reference.Name)
+	case coreTypes.EcsCapacityProviderReference:
+		return fmt.Sprintf("ECS capacity provider %s of the Auto Scaling group %s", reference.Name, reference.Id)
+	case coreTypes.LambdaFunctionReference:
+		if reference.Alias != "" {
+			return fmt.Sprintf("Lambda function %s alias %s (version %s)", reference.Name, reference.Alias,
+				reference.Version)
+		}
+		return fmt.Sprintf("Lambda function %s version %s", reference.Name, reference.Version)
+	default:
+		return reference.Name
+	}
+}
+
+// GetUnresolvedAttachmentText returns the description of a resolver which failed to check the Network Interface
+func GetUnresolvedAttachmentText(attachment coreTypes.UnresolvedAttachment) string {
+	return fmt.Sprintf("Note: the %s resolver failed, the ENI might be used by a resource which is not shown: %s",
+		attachment.Resolver, attachment.Error)
+}
+
+// GetRevokedRuleText returns the direction, the ID and the Security Group of a revoked rule, together with the traffic
+// it allowed, so that the rule can be restored
+func GetRevokedRuleText(revoked coreTypes.RevokedRule) string {
+	rule := revoked.Rule
+	direction, preposition := "inbound", "from"
+	if rule.IsEgress {
+		direction, preposition = "outbound", "to"
+	}
+
+	traffic := fmt.Sprintf("%s %d-%d", rule.Protocol, rule.FromPort, rule.ToPort)
+	if rule.Protocol == "-1" {
+		traffic = "all traffic"
+	}
+
+	text := fmt.Sprintf("%s rule %s of Security Group %s allowing %s %s %s", direction, rule.Id, rule.GroupId,
+		traffic, preposition, aws.ToString(rule.ReferencedGroupId))
+	if rule.Description != nil && *rule.Description != "" {
+		text = fmt.Sprintf("%s (%s)", text, *rule.Description)
+	}
+	return text
+}
+
+// GetSeverityText returns the severity of an audit finding in upper case, colored by its severity
+func GetSeverityText(severity string) string {
+	text := strings.ToUpper(severity)
+	switch severity {
+	case coreTypes.SeverityCritical:
+		return pterm.LightRed(text)
+	case coreTypes.SeverityHigh:
+		return pterm.Red(text)
+	case coreTypes.SeverityMedium:
+		return pterm.LightYellow(text)
+	default:
+		return pterm.LightBlue(text)
+	}
+}
+
+// GetNetworkInterfaceOwnerItems returns the bullet list items describing the resources owning a Network Interface,
+// followed by the resolvers which failed to check it
+func GetNetworkInterfaceOwnerItems(eni coreTypes.NetworkInterfaceDetails, level int) []pterm.BulletListItem {
+	items := make([]pterm.BulletListItem, 0)
+
+	owners := eni.Owners()
+	if len(owners) > 0 {
+		items = append(items, pterm.BulletListItem{
+			Level:       level,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        "Associated to:",
+		})
+	}
+	for _, owner := range owners {
+		text := owner.Details
+		switch {
+		case owner.IsUnresolved:
+			text = fmt.Sprintf("%s Note: no resource matches the ENI, it might still be in use.", text)
+		case owner.IsRemoved:
+			text = fmt.Sprintf("%s Note: the resource was removed. Please try to remove the ENI manually!", text)
+		}
+		items = append(items, pterm.BulletListItem{
+			Level:       level + 1,
+			TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
+			BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
+			Text:        text,
+		})
+	}
+
+	for _, attachment := range eni.UnresolvedAttachments {
+		items = append(items, pterm.BulletListItem{
+			Level:       level,
+			TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
+			BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
+			Text:        GetUnresolvedAttachmentText(attachment),
+		})
+	}
+	return items
+}
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// ValidateOutputFormat returns an error if the output format provided is not supported
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case OutputText, OutputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q, expected one of: %s, %s", format, OutputText, OutputJSON)
+	}
+}
+
+// PrintRemovalPlan prints the resources which will be removed by a plan, and the ones which were skipped
+func PrintRemovalPlan(plan *core.RemovalPlan, resourceName string) {
+	pterm.DefaultSection.Println("Removal plan")
+
+	bulletList := make([]pterm.BulletListItem, 0)
+	if len(plan.Items) > 0 {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        fmt.Sprintf("%s(s) to be removed:", resourceName),
+		})
+		for _, item := range plan.Items {
+			location := pterm.Cyan(GetLocationText(item.AccountId, item.Region))
+			text := fmt.Sprintf("%s [%s]", pterm.LightGreen(item.Id), location)
+			if item.Name != "" {
+				text = fmt.Sprintf("%s (%s) [%s]", item.Name, pterm.LightGreen(item.Id), location)
+			}
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       1,
+				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+				Text:        text,
+			})
+		}
+	} else {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        fmt.Sprintf("No %s can be removed.", resourceName),
+		})
+	}
+
+	if len(plan.Skipped) > 0 {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        fmt.Sprintf("%s(s) which cannot be removed:", resourceName),
+		})
+		for _, item := range plan.Skipped {
+			text := pterm.LightRed(item.Id)
+			if item.AccountId != "" || item.Region != "" {
+				text = fmt.Sprintf("%s [%s]", text, pterm.Cyan(GetLocationText(item.AccountId, item.Region)))
+			}
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       1,
+				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+				Text:        text,
+			})
+			for _, reason := range item.Reasons {
+				bulletList = append(bulletList, pterm.BulletListItem{
+					Level:       2,
+					TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
+					BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
+					Text:        reason,
+				})
+			}
+		}
+	}
+
+	_ = pterm.DefaultBulletList.WithItems(bulletList).Render()
+}
+
+// DefaultBackupDir returns the default directory for the Security Group backups, or an empty string if it cannot be
+// determined
+func DefaultBackupDir() string {
+	dir, err := core.DefaultBackupDir()
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+// ReportScanError prints a warning for every account and region which could not be scanned if the error is a
+// core.ScanError, so that the results of the other accounts and regions can still be shown. The warnings are written to
+// the standard error, keeping the JSON output valid. Any other error is returned
+func ReportScanError(err error) error {
+	var scanErr *core.ScanError
+	if !errors.As(err, &scanErr) {
+		return err
+	}
+
+	for _, targetErr := range scanErr.Errors {
+		pterm.Warning.WithWriter(os.Stderr).Printfln("Skipping %s: %v",
+			GetLocationText(targetErr.AccountId, targetErr.Region), targetErr.Err)
+	}
+	return nil
+}
+
+// GetLocationText returns the account and the region in which a resource exists
+func GetLocationText(accountId string, region string) string {
+	return fmt.Sprintf("%s/%s", accountId, region)
+}
+
+// GetScope builds the scope of a command from the global flags, including the protection of the resources. The region flag is merged into the regions. Returns an
+// error if every region is requested together with explicit regions, or if other accounts are requested without a role
+func GetScope(cmd *cobra.Command) (core.Scope, error) {
+	scope := core.Scope{}
+
+	profileFlag := cmd.Flags().Lookup("profile")
+	if profileFlag != nil {
+		scope.Profile = profileFlag.Value.String()
+	}
+
+	regionFlag := cmd.Flags().Lookup("region")
+	if regionFlag != nil && regionFlag.Value.String() != "" {
+		scope.Regions = append(scope.Regions, regionFlag.Value.String())
+	}
+
+	if cmd.Flags().Lookup("regions") != nil {
+		regions, err := cmd.Flags().GetStringSlice("regions")
+		if err != nil {
+			return scope, err
+		}
+		for _, region := range regions {
+			if !slices.Contains(scope.Regions, region) {
+				scope.Regions = append(scope.Regions, region)
+			}
+		}
+	}
+
+	if cmd.Flags().Lookup("all-regions") != nil {
+		allRegions, err := cmd.Flags().GetBool("all-regions")
+		if err != nil {
+			return scope, err
+		}
+		if allRegions && len(scope.Regions) > 0 {
+			return scope, fmt.Errorf("--all-regions cannot be used together with --region or --regions")
+		}
+		scope.AllRegions = allRegions
+	}
+
+	if cmd.Flags().Lookup("accounts") != nil {
+		accounts, err := cmd.Flags().GetStringSlice("accounts")
+		if err != nil {
+			return scope, err
+		}
+		scope.Accounts = accounts
+	}
+
+	if cmd.Flags().Lookup("organization") != nil {
+		organization, err := cmd.Flags().GetBool("organization")
+		if err != nil {
+			return scope, err
+		}
+		scope.OrganizationAccounts = organization
+	}
+
+	roleNameFlag := cmd.Flags().Lookup("role-name")
+	if roleNameFlag != nil {
+		scope.RoleName = roleNameFlag.Value.String()
+	}
+
+	if (len(scope.Accounts) > 0 || scope.OrganizationAccounts) && scope.RoleName == "" {
+		return scope, fmt.Errorf("--role-name is required when using --accounts or --organization")
+	}
+
+	protection, err := getProtection(cmd)
+	if err != nil {
+		return scope, err
+	}
+	scope.Protection = protection
+
+	return scope, nil
+}
+
+// Build the protection from the protection tag and the keep-list flags. The keep-list file is ignored if it does not
+// exist, unless it was provided explicitly
+func getProtection(cmd *cobra.Command) (core.Protection, error) {
+	protection := core.Protection{}
+
+	protectionTagFlag := cmd.Flags().Lookup("protection-tag")
+	if protectionTagFlag != nil && protectionTagFlag.Value.String() != "" {
+		selector, err := core.ParseTagSelector(protectionTagFlag.Value.String())
+		if err != nil {
+			return protection, err
+		}
+		protection.Tag = &selector
+	}
+
+	keepListFlag := cmd.Flags().Lookup("keep-list")
+	if keepListFlag != nil && keepListFlag.Value.String() != "" {
+		keepList, err := core.ReadKeepList(keepListFlag.Value.String())
+		if err != nil && (keepListFlag.Changed || !errors.Is(err, os.ErrNotExist)) {
+			return protection, err
+		}
+		protection.KeepList = keepList
+	}
+
+	return protection, nil
+}
+
+// DefaultKeepListFile returns the default keep-list file, or an empty string if it cannot be determined
+func DefaultKeepListFile() string {
+	path, err := core.DefaultKeepListFile()
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,92 @@
+package cmdutils
+
+import (
+	"fmt"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/spf13/cobra"
+	"regexp"
+)
+
+// FilterFlags holds the values of the flags used for filtering Security Groups and Network Interfaces
+type FilterFlags struct {
+	vpcIds             []string
+	subnetIds          []string
+	tags               []string
+	excludeTags        []string
+	namePattern        string
+	descriptionPattern string
+	interfaceTypes     []string
+}
+
+// IncludeFilterFlags registers the filter flags on the command
+func IncludeFilterFlags(cmd *cobra.Command, flags *FilterFlags) {
+	cmd.Flags().StringSliceVar(&flags.vpcIds, "vpc", nil,
+		"[Optional] VPC ID to be filtered. It can accept multiple values divided by comma.")
+	cmd.Flags().StringSliceVar(&flags.subnetIds, "subnet", nil,
+		"[Optional] Subnet ID to be filtered. Security Groups are matched by the subnets of the Network Interfaces "+
+			"using them. It can accept multiple values divided by comma.")
+	cmd.Flags().StringSliceVar(&flags.tags, "tag", nil,
+		"[Optional] Tag which has to be present, in the form of key=value or key. It can accept multiple values "+
+			"divided by comma, every tag has to match.")
+	cmd.Flags().StringSliceVar(&flags.excludeTags, "exclude-tag", nil,
+		"[Optional] Tag which must not be present, in the form of key=value or key. It can accept multiple values "+
+			"divided by comma.")
+	cmd.Flags().StringVar(&flags.namePattern, "name-regex", "",
+		"[Optional] Regular expression matching the name. The name of a Network Interface is its Name tag.")
+	cmd.Flags().StringVar(&flags.descriptionPattern, "description-regex", "",
+		"[Optional] Regular expression matching the description.")
+	cmd.Flags().StringSliceVar(&flags.interfaceTypes, "type", nil,
+		"[Optional] Network Interface type to be filtered (e.g. interface, lambda, vpc_endpoint). Security Groups are "+
+			"matched by the types of the Network Interfaces using them. It can accept multiple values divided by comma.")
+}
+
+// IsSet returns true if at least one filter flag was provided
+func (f *FilterFlags) IsSet() bool {
+	return len(f.vpcIds) > 0 || len(f.subnetIds) > 0 || len(f.tags) > 0 || len(f.excludeTags) > 0 ||
+		f.namePattern != "" || f.descriptionPattern != "" || len(f.interfaceTypes) > 0
+}
+
+// ToFilters validates the values of the filter flags and converts them to core.Filters with the status provided
+func (f *FilterFlags) ToFilters(status core.SecurityGroupStatus) (core.Filters, error) {
+	filters := core.Filters{
+		Status:    status,
+		VpcIds:    f.vpcIds,
+		SubnetIds: f.subnetIds,
+	}
+
+	for _, tag := range f.tags {
+		selector, err := core.ParseTagSelector(tag)
+		if err != nil {
+			return filters, err
+		}
+		filters.IncludeTags = append(filters.IncludeTags, selector)
+	}
+
+	for _, tag := range f.excludeTags {
+		selector, err := core.ParseTagSelector(tag)
+		if err != nil {
+			return filters, err
+		}
+		filters.ExcludeTags = append(filters.ExcludeTags, selector)
+	}
+
+	if f.namePattern != "" {
+		namePattern, err := regexp.Compile(f.namePattern)
+		if err != nil {
+			return filters, fmt.Errorf("invalid name regular expression: %w", err)
+		}
+		filters.NamePattern = namePattern
+	}
+
+	if f.descriptionPattern != "" {
+		descriptionPattern, err := regexp.Compile(f.descriptionPattern)
+		if err != nil {
+			return filters, fmt.Errorf("invalid description regular expression: %w", err)
+		}
+		filters.DescriptionPattern = descriptionPattern
+	}
+
+	filters.InterfaceTypes = f.interfaceTypes
+
+	return filters, nil
+}
@@ -0,0 +1,96 @@
+package cmdutils
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFilterFlagsToFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		isSet    bool
+		expected core.Filters
+		hasError bool
+	}{
+		{
+			name:     "no flags",
+			expected: core.Filters{Status: core.Unused},
+		},
+		{
+			name: "every flag",
+			args: []string{"--vpc", "vpc-1,vpc-2", "--subnet", "subnet-1", "--tag", "team=payments,env",
+				"--exclude-tag", "keep", "--type", "interface,lambda"},
+			isSet: true,
+			expected: core.Filters{
+				Status:    core.Unused,
+				VpcIds:    []string{"vpc-1", "vpc-2"},
+				SubnetIds: []string{"subnet-1"},
+				IncludeTags: []core.TagSelector{
+					{Key: "team", Value: aws.String("payments")},
+					{Key: "env"},
+				},
+				ExcludeTags:    []core.TagSelector{{Key: "keep"}},
+				InterfaceTypes: []string{"interface", "lambda"},
+			},
+		},
+		{
+			name:     "invalid tag",
+			args:     []string{"--tag", "=payments"},
+			isSet:    true,
+			hasError: true,
+		},
+		{
+			name:     "invalid excluded tag",
+			args:     []string{"--exclude-tag", "=payments"},
+			isSet:    true,
+			hasError: true,
+		},
+		{
+			name:     "invalid name regular expression",
+			args:     []string{"--name-regex", "payments-("},
+			isSet:    true,
+			hasError: true,
+		},
+		{
+			name:     "invalid description regular expression",
+			args:     []string{"--description-regex", "["},
+			isSet:    true,
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flags FilterFlags
+			cmd := &cobra.Command{}
+			IncludeFilterFlags(cmd, &flags)
+			require.NoError(t, cmd.ParseFlags(tt.args))
+			require.Equal(t, tt.isSet, flags.IsSet())
+
+			filters, err := flags.ToFilters(core.Unused)
+			if tt.hasError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, filters)
+		})
+	}
+}
+
+func TestFilterFlagsToFiltersPatterns(t *testing.T) {
+	var flags FilterFlags
+	cmd := &cobra.Command{}
+	IncludeFilterFlags(cmd, &flags)
+	require.NoError(t, cmd.ParseFlags([]string{"--name-regex", "^payments-", "--description-regex", "api"}))
+
+	filters, err := flags.ToFilters(core.All)
+	require.NoError(t, err)
+	require.True(t, filters.NamePattern.MatchString("payments-api"))
+	require.False(t, filters.NamePattern.MatchString("orders-api"))
+	require.True(t, filters.DescriptionPattern.MatchString("public api"))
+}
@@ -0,0 +1,142 @@
+package dedupe
+
+import (
+	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/analysis"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/output"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "dedupe",
+		Short: "Find duplicate and equivalent Security Groups.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := cmdutils.ValidateOutputFormat(outputFormat); err != nil {
+				return err
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			return err
+		},
+		RunE: runDedupe,
+	}
+
+	sg           *[]string
+	scope        core.Scope
+	outputFormat string
+	filters      core.Filters
+	filterFlags  cmdutils.FilterFlags
+)
+
+func runDedupe(cmd *cobra.Command, args []string) error {
+	groups, err := core.ListSecurityGroupsInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	report := analysis.Dedupe(groups)
+	if outputFormat == cmdutils.OutputJSON {
+		return output.WriteJSON(os.Stdout, output.NewDedupeDocument(report))
+	}
+
+	printReport(report)
+	return nil
+}
+
+func printReport(report coreTypes.DedupeReport) {
+	pterm.DefaultSection.Println("Duplicate Security Groups")
+	if len(report.Duplicates) == 0 {
+		pterm.Info.Println("No duplicate Security Groups found.")
+	} else {
+		bulletList := make([]pterm.BulletListItem, 0)
+		for _, duplicates := range report.Duplicates {
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       0,
+				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+				Text: fmt.Sprintf("%d Security Groups allowing the same traffic in %s [%s]:",
+					len(duplicates.SecurityGroups), duplicates.VpcId,
+					pterm.Cyan(cmdutils.GetLocationText(duplicates.AccountId, duplicates.Region))),
+			})
+			bulletList = appendMembers(bulletList, duplicates.SecurityGroups, 1)
+		}
+		_ = pterm.DefaultBulletList.WithItems(bulletList).Render()
+	}
+
+	pterm.DefaultSection.Println("Security Groups covered by other Security Groups")
+	if len(report.Subsets) == 0 {
+		pterm.Info.Println("No Security Group allows a strict subset of the traffic of another one.")
+		return
+	}
+
+	bulletList := make([]pterm.BulletListItem, 0)
+	for _, subset := range report.Subsets {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text: fmt.Sprintf("In %s [%s], the traffic allowed by:", subset.VpcId,
+				pterm.Cyan(cmdutils.GetLocationText(subset.AccountId, subset.Region))),
+		})
+		bulletList = appendMembers(bulletList, subset.Subset, 1)
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        "is also allowed by:",
+		})
+		bulletList = appendMembers(bulletList, subset.Superset, 1)
+	}
+	_ = pterm.DefaultBulletList.WithItems(bulletList).Render()
+}
+
+func appendMembers(bulletList []pterm.BulletListItem, members []coreTypes.DedupeMember,
+	level int) []pterm.BulletListItem {
+	for _, member := range members {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       level,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text: fmt.Sprintf("%s (%s), %d rule(s)", member.Name, pterm.LightGreen(member.Id),
+				member.RuleCount),
+		})
+
+		usedBy := pterm.LightYellow("none")
+		if len(member.UsedBy) > 0 {
+			usedBy = pterm.LightBlue(strings.Join(member.UsedBy, ", "))
+		}
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       level + 1,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        fmt.Sprintf("Used by Network Interface(s): %s", usedBy),
+		})
+	}
+	return bulletList
+}
+
+func init() {
+	includeValidateFlags(Cmd)
+}
+
+func includeValidateFlags(cmd *cobra.Command) {
+	sg = cmd.Flags().StringSlice("sg", nil,
+		"[Optional] Security Group Id to be compared. It can accept multiple values divided by comma. "+
+			"Default: none (if none is specified all security groups will be compared)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", cmdutils.OutputText,
+		"[Optional] Output format. Accepted values: text, json.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+}
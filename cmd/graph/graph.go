@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/graph"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Export the references of Security Groups as a graph.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := graph.ValidateFormat(format); err != nil {
+				return err
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			return err
+		},
+		RunE: runGraph,
+	}
+
+	sg          *[]string
+	scope       core.Scope
+	format      string
+	outputFile  string
+	filters     core.Filters
+	filterFlags cmdutils.FilterFlags
+)
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	groups, err := core.ListSecurityGroupsInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	g := graph.NewGraph(groups)
+	if outputFile == "" {
+		return graph.Write(os.Stdout, g, format)
+	}
+
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	if err := graph.Write(f, g, format); err != nil {
+		// Do not leave a partial graph behind
+		f.Close()
+		os.Remove(outputFile)
+		return err
+	}
+	return f.Close()
+}
+
+func init() {
+	includeValidateFlags(Cmd)
+}
+
+func includeValidateFlags(cmd *cobra.Command) {
+	sg = cmd.Flags().StringSlice("sg", nil,
+		"[Optional] Security Group Id to be included. It can accept multiple values divided by comma. "+
+			"Default: none (if none is specified all security groups will be included)")
+	cmd.Flags().StringVarP(&format, "format", "f", graph.FormatDOT,
+		"[Optional] Graph format. Accepted values: dot, mermaid, graphml.")
+	cmd.Flags().StringVar(&outputFile, "output-file", "",
+		"[Optional] File in which the graph is written. Default: standard output")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
 	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/output"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
+	"os"
 )
 
 var (
@@ -14,42 +16,48 @@ var (
 		Use:   "list",
 		Short: "List Security Groups with Details",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			regionFlag := cmd.Flags().Lookup("region")
-			if regionFlag != nil {
-				region = regionFlag.Value.String()
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
 			}
 
-			profileFlag := cmd.Flags().Lookup("profile")
-			if profileFlag != nil {
-				profile = profileFlag.Value.String()
-			}
-
-			return nil
+			return cmdutils.ValidateOutputFormat(outputFormat)
 		},
 		RunE: runList,
 	}
 
-	used    bool
-	unused  bool
-	region  string
-	profile string
-	sg      *[]string
+	used         bool
+	unused       bool
+	scope        core.Scope
+	outputFormat string
+	filterFlags  cmdutils.FilterFlags
+	sg           *[]string
 )
 
 func runList(cmd *cobra.Command, args []string) error {
-	filters := core.Filters{Status: core.All}
+	status := core.All
 	if used {
-		filters.Status = core.Used
+		status = core.Used
 	}
 	if unused {
-		filters.Status = core.Unused
+		status = core.Unused
 	}
 
-	groups, err := core.ListSecurityGroups(cmd.Context(), *sg, filters, region, profile)
+	filters, err := filterFlags.ToFilters(status)
 	if err != nil {
 		return err
 	}
 
+	groups, err := core.ListSecurityGroupsInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	if outputFormat == cmdutils.OutputJSON {
+		return output.WriteJSON(os.Stdout, output.NewSecurityGroupsDocument(groups))
+	}
+
 	for _, sg := range groups {
 		err := printSecurityGroupDetails(sg)
 		if err != nil {
@@ -61,9 +69,9 @@ func runList(cmd *cobra.Command, args []string) error {
 }
 
 func printSecurityGroupDetails(sg types.SecurityGroupDetails) error {
-	pterm.DefaultSection.Printf("%s (%s)", sg.Name, sg.Id)
+	pterm.DefaultSection.Printf("%s (%s) [%s]", sg.Name, sg.Id, cmdutils.GetLocationText(sg.AccountId, sg.Region))
 
-	reasons := getReasonsAgainstRemoval(sg)
+	reasons := sg.ReasonsAgainstRemoval()
 	var canBeRemoved string
 	if sg.CanBeRemoved() {
 		canBeRemoved = pterm.LightGreen("YES")
@@ -103,6 +111,27 @@ func printSecurityGroupDetails(sg types.SecurityGroupDetails) error {
 		}
 	}
 
+	if sg.IsRemovableAsSet() {
+		title := "Removable as a set, in this order:"
+		if sg.RemovalSetHasCycles {
+			title = "Removable as a set, in this order, after revoking the rules referencing each other:"
+		}
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       1,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        title,
+		})
+		for _, sgId := range sg.RemovalSet {
+			bulletList = append(bulletList, pterm.BulletListItem{
+				Level:       2,
+				TextStyle:   pterm.NewStyle(pterm.FgCyan),
+				BulletStyle: pterm.NewStyle(pterm.FgCyan),
+				Text:        sgId,
+			})
+		}
+	}
+
 	if len(sg.UsedBy) > 0 {
 		bulletList = append(bulletList, pterm.BulletListItem{
 			Level:       0,
@@ -135,169 +164,7 @@ func printSecurityGroupDetails(sg types.SecurityGroupDetails) error {
 				Text:        fmt.Sprintf("Status: %s", cmdutils.GetENIStatusColor(eni.Status)),
 			})
 
-			if eni.EC2Attachment != nil {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Attached to EC2 instance:",
-				})
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       3,
-					TextStyle:   pterm.NewStyle(pterm.FgCyan),
-					BulletStyle: pterm.NewStyle(pterm.FgCyan),
-					Text:        fmt.Sprintf("%s", eni.EC2Attachment.InstanceId),
-				})
-			}
-
-			if eni.LambdaAttachment != nil {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Associated to Lambda Function:",
-				})
-
-				if eni.LambdaAttachment.IsRemoved {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s - Note: This function was already removed. Please wait 15-20 minutes for the ENI to be removed by AWS.",
-							eni.LambdaAttachment.Name),
-					})
-				} else {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text:        fmt.Sprintf("%s (%s)", eni.LambdaAttachment.Name, *eni.LambdaAttachment.Arn),
-					})
-				}
-			}
-
-			if eni.ECSAttachment != nil {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Associated to ECS Container:",
-				})
-
-				cluster := "unknown"
-				if eni.ECSAttachment.ClusterArn != nil {
-					cluster = *eni.ECSAttachment.ClusterArn
-				}
-
-				taskArn := "unknown"
-				if eni.ECSAttachment.TaskArn != nil {
-					taskArn = *eni.ECSAttachment.TaskArn
-				}
-
-				container := "unknown"
-				if eni.ECSAttachment.ContainerName != nil {
-					container = *eni.ECSAttachment.ContainerName
-				}
-
-				if eni.ECSAttachment.IsRemoved {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text:        "Note: the associated task was already removed. Please try to remove the ENI manually!"})
-				} else {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-						BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-						Text:        fmt.Sprintf("Cluster: %s", pterm.Cyan(cluster)),
-					})
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-						BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-						Text:        fmt.Sprintf("Task: %s", pterm.Cyan(taskArn)),
-					})
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-						BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-						Text:        fmt.Sprintf("Container: %s", pterm.Cyan(container)),
-					})
-				}
-			}
-
-			if eni.ELBAttachment != nil {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Associated to Load Balancer:",
-				})
-
-				if eni.ELBAttachment.IsRemoved {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s Note: the load balancer was removed. Please try to remove the ENI manually!",
-							eni.ELBAttachment.Name),
-					})
-				} else {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s (%s)",
-							eni.ELBAttachment.Name, *eni.ELBAttachment.Arn),
-					})
-				}
-			}
-
-			if eni.VPCEAttachment != nil {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Associated to VPC Endpoint:",
-				})
-
-				if eni.VPCEAttachment.IsRemoved {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s Note: the VPC Endpoint was removed. Please try to remove the ENI manually!",
-							*eni.VPCEAttachment.Id),
-					})
-				} else {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s (%s)",
-							*eni.VPCEAttachment.ServiceName, *eni.VPCEAttachment.Id),
-					})
-				}
-			}
-
-			if len(eni.RDSAttachments) > 0 {
-				bulletList = append(bulletList, pterm.BulletListItem{
-					Level:       2,
-					TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-					BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-					Text:        "Associated to RDS instance (might be inaccurate):",
-				})
-				for _, attachment := range eni.RDSAttachments {
-					bulletList = append(bulletList, pterm.BulletListItem{
-						Level:       3,
-						TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-						BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-						Text: fmt.Sprintf("%s",
-							attachment.Identifier),
-					})
-				}
-			}
+			bulletList = append(bulletList, cmdutils.GetNetworkInterfaceOwnerItems(eni, 2)...)
 		}
 	}
 
@@ -317,23 +184,39 @@ func printSecurityGroupDetails(sg types.SecurityGroupDetails) error {
 		}
 	}
 
-	return pterm.DefaultBulletList.WithItems(bulletList).Render()
-}
+	if len(sg.ConfigurationReferences) > 0 {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        "Referenced by the following configurations:",
+		})
 
-func getReasonsAgainstRemoval(sg types.SecurityGroupDetails) []string {
-	reasons := make([]string, 0)
-	if !sg.CanBeRemoved() {
-		if sg.Default {
-			reasons = append(reasons, fmt.Sprintf("Security Group is Default in VPC %s", sg.VpcId))
-		}
-		if len(sg.UsedBy) > 0 {
-			reasons = append(reasons, "Security Group is used by an Elastic Network Interface (ENI)")
+		for _, reference := range sg.ConfigurationReferences {
+			bulletList = append(bulletList, pterm.BulletListItem{Level: 1,
+				TextStyle:   pterm.NewStyle(pterm.FgCyan),
+				BulletStyle: pterm.NewStyle(pterm.FgCyan),
+				Text:        cmdutils.GetConfigurationReferenceText(reference)})
 		}
-		if len(sg.RuleReferences) > 0 {
-			reasons = append(reasons, "Security Group is references by a Security Group Rule")
+	}
+
+	if len(sg.UncheckedConfigurations) > 0 {
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text:        "Configurations which could not be checked for references:",
+		})
+
+		for _, source := range sg.UncheckedConfigurations {
+			bulletList = append(bulletList, pterm.BulletListItem{Level: 1,
+				TextStyle:   pterm.NewStyle(pterm.FgYellow),
+				BulletStyle: pterm.NewStyle(pterm.FgYellow),
+				Text:        source})
 		}
 	}
-	return reasons
+
+	return pterm.DefaultBulletList.WithItems(bulletList).Render()
 }
 
 func init() {
@@ -348,4 +231,7 @@ func includeValidateFlags(cmd *cobra.Command) {
 		"[Optional] List all security groups.")
 	cmd.Flags().BoolVarP(&unused, "unused", "n", false,
 		"[Optional] List unused security groups security groups.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", cmdutils.OutputText,
+		"[Optional] Output format. Accepted values: text, json.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
 }
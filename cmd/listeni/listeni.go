@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
 	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/output"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
+	"os"
 	"strings"
 )
 
@@ -16,40 +18,47 @@ var (
 		Short: "List Elastic Network Interfaces with Details",
 		RunE:  runList,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			regionFlag := cmd.Flags().Lookup("region")
-			if regionFlag != nil {
-				region = regionFlag.Value.String()
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
 			}
 
-			profileFlag := cmd.Flags().Lookup("profile")
-			if profileFlag != nil {
-				profile = profileFlag.Value.String()
-			}
-
-			return nil
+			return cmdutils.ValidateOutputFormat(outputFormat)
 		},
 	}
 
-	used    bool
-	unused  bool
-	region  string
-	profile string
-	sg      *[]string
+	used         bool
+	unused       bool
+	scope        core.Scope
+	outputFormat string
+	filterFlags  cmdutils.FilterFlags
+	sg           *[]string
 )
 
 func runList(cmd *cobra.Command, args []string) error {
-	filters := core.Filters{Status: core.All}
+	status := core.All
 	if used {
-		filters.Status = core.Used
+		status = core.Used
 	}
 	if unused {
-		filters.Status = core.Unused
+		status = core.Unused
 	}
 
-	enis, err := core.ListNetworkInterfaces(cmd.Context(), *sg, filters, region, profile)
+	filters, err := filterFlags.ToFilters(status)
 	if err != nil {
 		return err
 	}
+
+	enis, err := core.ListNetworkInterfacesInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	if outputFormat == cmdutils.OutputJSON {
+		return output.WriteJSON(os.Stdout, output.NewNetworkInterfacesDocument(enis))
+	}
+
 	for _, eni := range enis {
 		err := printEniUsage(eni)
 		if err != nil {
@@ -61,7 +70,7 @@ func runList(cmd *cobra.Command, args []string) error {
 }
 
 func printEniUsage(eni coreTypes.NetworkInterfaceDetails) error {
-	pterm.DefaultSection.Printf("%s", eni.Id)
+	pterm.DefaultSection.Printf("%s [%s]", eni.Id, cmdutils.GetLocationText(eni.AccountId, eni.Region))
 	var bulletList []pterm.BulletListItem
 	if eni.Description != nil {
 		bulletList = append(bulletList, pterm.BulletListItem{
@@ -108,167 +117,7 @@ func printEniUsage(eni coreTypes.NetworkInterfaceDetails) error {
 		Text:        fmt.Sprintf("Status: %s", cmdutils.GetENIStatusColor(eni.Status)),
 	})
 
-	if eni.EC2Attachment != nil {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Attached to EC2 instance:"},
-		)
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       2,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        fmt.Sprintf("%s", pterm.Cyan(eni.EC2Attachment.InstanceId)),
-		})
-	}
-
-	if eni.LambdaAttachment != nil {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Associated to Lambda Function:",
-		})
-
-		if eni.LambdaAttachment.IsRemoved {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text: fmt.Sprintf(
-					"%s - Note: This function was already removed. Please wait 15-20 minutes for the ENI to be removed by AWS.",
-					eni.LambdaAttachment.Name),
-			})
-		} else {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text: fmt.Sprintf("%s (%s)",
-					eni.LambdaAttachment.Name, *eni.LambdaAttachment.Arn),
-			})
-		}
-	}
-	if eni.ECSAttachment != nil {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Associated to ECS Container:",
-		})
-
-		cluster := "unknown"
-		if eni.ECSAttachment.ClusterArn != nil {
-			cluster = *eni.ECSAttachment.ClusterArn
-		}
-
-		taskArn := "unknown"
-		if eni.ECSAttachment.TaskArn != nil {
-			taskArn = *eni.ECSAttachment.TaskArn
-		}
-
-		container := "unknown"
-		if eni.ECSAttachment.ContainerName != nil {
-			container = *eni.ECSAttachment.ContainerName
-		}
-
-		if eni.ECSAttachment.IsRemoved {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text:        "Note: the associated task was already removed. Please try to remove the ENI manually!"})
-		} else {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-				Text:        fmt.Sprintf("Cluster: %s", pterm.Cyan(cluster)),
-			})
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-				Text:        fmt.Sprintf("Task: %s", pterm.Cyan(taskArn)),
-			})
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-				BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-				Text:        fmt.Sprintf("Container: %s", pterm.Cyan(container)),
-			})
-		}
-	}
-
-	if eni.ELBAttachment != nil {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Associated to Load Balancer:",
-		})
-
-		if eni.ELBAttachment.IsRemoved {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text:        fmt.Sprintf("%s Note: the load balancer was removed. Please try to remove the ENI manually!", eni.ELBAttachment.Name),
-			})
-		} else {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text:        fmt.Sprintf("%s (%s)", eni.ELBAttachment.Name, *eni.ELBAttachment.Arn),
-			})
-		}
-
-	}
-	if eni.VPCEAttachment != nil {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Associated to VPC Endpoint:",
-		})
-
-		if eni.VPCEAttachment.IsRemoved {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text:        fmt.Sprintf("%s Note: the VPC Endpoint was removed. Please try to remove the ENI manually!", *eni.VPCEAttachment.Id),
-			})
-		} else {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text:        fmt.Sprintf("%s (%s)", *eni.VPCEAttachment.ServiceName, *eni.VPCEAttachment.Id),
-			})
-		}
-	}
-
-	if len(eni.RDSAttachments) > 0 {
-		bulletList = append(bulletList, pterm.BulletListItem{
-			Level:       1,
-			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
-			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
-			Text:        "Associated to RDS instance (might be inaccurate):",
-		})
-
-		for _, attachment := range eni.RDSAttachments {
-			bulletList = append(bulletList, pterm.BulletListItem{
-				Level:       2,
-				TextStyle:   pterm.NewStyle(pterm.FgLightYellow),
-				BulletStyle: pterm.NewStyle(pterm.FgLightYellow),
-				Text: fmt.Sprintf("%s",
-					attachment.Identifier),
-			})
-		}
-	}
+	bulletList = append(bulletList, cmdutils.GetNetworkInterfaceOwnerItems(eni, 1)...)
 
 	if len(eni.SecurityGroupIdentifiers) > 0 {
 		bulletList = append(bulletList, pterm.BulletListItem{
@@ -306,4 +155,7 @@ func includeValidateFlags(cmd *cobra.Command) {
 		"[Optional] List all network interfaces.")
 	cmd.Flags().BoolVarP(&unused, "unused", "n", false,
 		"[Optional] List unused network interfaces.")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", cmdutils.OutputText,
+		"[Optional] Output format. Accepted values: text, json.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
 }
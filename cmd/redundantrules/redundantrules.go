@@ -0,0 +1,115 @@
+package redundantrules
+
+import (
+	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/analysis"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/output"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "redundant-rules",
+		Short: "Find redundant rules and rules referencing removed Security Groups.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := cmdutils.ValidateOutputFormat(outputFormat); err != nil {
+				return err
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			return err
+		},
+		RunE: runRedundantRules,
+	}
+
+	sg           *[]string
+	scope        core.Scope
+	outputFormat string
+	filters      core.Filters
+	filterFlags  cmdutils.FilterFlags
+)
+
+func runRedundantRules(cmd *cobra.Command, args []string) error {
+	findings, err := core.ListRedundantRulesInScope(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	if outputFormat == cmdutils.OutputJSON {
+		return output.WriteJSON(os.Stdout, output.NewRuleFindingsDocument(findings))
+	}
+
+	if len(findings) == 0 {
+		pterm.Info.Println("No redundant rule found.")
+		return nil
+	}
+
+	for start := 0; start < len(findings); {
+		end := start
+		for end < len(findings) && isSameSecurityGroup(findings[start], findings[end]) {
+			end++
+		}
+		printSecurityGroupFindings(findings[start:end])
+		start = end
+	}
+	return nil
+}
+
+func isSameSecurityGroup(a coreTypes.RuleFinding, b coreTypes.RuleFinding) bool {
+	return a.AccountId == b.AccountId && a.Region == b.Region && a.SecurityGroupId == b.SecurityGroupId
+}
+
+// Print the findings of a single Security Group
+func printSecurityGroupFindings(findings []coreTypes.RuleFinding) {
+	sg := findings[0]
+	pterm.DefaultSection.Printf("%s (%s) [%s]", sg.SecurityGroupName, sg.SecurityGroupId,
+		cmdutils.GetLocationText(sg.AccountId, sg.Region))
+
+	bulletList := []pterm.BulletListItem{
+		{
+			Level:       0,
+			TextStyle:   pterm.NewStyle(pterm.FgLightWhite),
+			BulletStyle: pterm.NewStyle(pterm.FgLightWhite),
+			Text: fmt.Sprintf("Rules: %s, of which can be removed: %s", pterm.Cyan(sg.RuleCount),
+				pterm.LightGreen(len(findings))),
+		},
+	}
+	for _, finding := range findings {
+		style := pterm.NewStyle(pterm.FgLightWhite)
+		if finding.Check == analysis.CheckMissingReference {
+			style = pterm.NewStyle(pterm.FgLightYellow)
+		}
+		bulletList = append(bulletList, pterm.BulletListItem{
+			Level:       1,
+			TextStyle:   style,
+			BulletStyle: style,
+			Text:        finding.Message,
+		})
+	}
+
+	_ = pterm.DefaultBulletList.WithItems(bulletList).Render()
+}
+
+func init() {
+	includeValidateFlags(Cmd)
+}
+
+func includeValidateFlags(cmd *cobra.Command) {
+	sg = cmd.Flags().StringSlice("sg", nil,
+		"[Optional] Security Group Id to be analyzed. It can accept multiple values divided by comma. "+
+			"Default: none (if none is specified all security groups will be analyzed)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", cmdutils.OutputText,
+		"[Optional] Output format. Accepted values: text, json.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+}
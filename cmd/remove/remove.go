@@ -2,7 +2,9 @@ package remove
 
 import (
 	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
 	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -14,32 +16,73 @@ var (
 		Short: "Remove unused Security Groups.",
 		Run:   runRemove,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			regionFlag := cmd.Flags().Lookup("region")
-			if regionFlag != nil {
-				region = regionFlag.Value.String()
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
 			}
 
-			profileFlag := cmd.Flags().Lookup("profile")
-			if profileFlag != nil {
-				profile = profileFlag.Value.String()
+			if len(*sg) <= 0 && planFile == "" && applyFile == "" && !filterFlags.IsSet() {
+				return fmt.Errorf("no Security Group ID or filter provided")
 			}
 
-			if len(*sg) <= 0 {
-				return fmt.Errorf("no Security Group ID provided")
+			if applyFile != "" && filterFlags.IsSet() {
+				return fmt.Errorf("filters cannot be used together with --apply")
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			if err != nil {
+				return err
+			}
+
+			if !noBackup && backupDir == "" {
+				return fmt.Errorf("no backup directory provided, use --backup-dir or --no-backup")
 			}
 
 			return nil
 		},
 	}
 
-	sg      *[]string
-	region  string
-	profile string
+	sg          *[]string
+	scope       core.Scope
+	planFile    string
+	applyFile   string
+	filters     core.Filters
+	filterFlags cmdutils.FilterFlags
+	force       bool
+	cascade     bool
+	backupDir   string
+	noBackup    bool
 )
 
 func runRemove(cmd *cobra.Command, args []string) {
-	resultCh := make(chan utils.Result[string])
-	err := core.RemoveSecurityGroupsAsync(cmd.Context(), *sg, region, profile, resultCh)
+	if planFile != "" {
+		runPlan(cmd)
+		return
+	}
+
+	options := core.RemoveOptions{Force: force, Cascade: cascade}
+	if cascade {
+		options.OnRuleRevoked = func(rule coreTypes.RevokedRule) {
+			pterm.Warning.Println("Revoked " + cmdutils.GetRevokedRuleText(rule) + " [" +
+				pterm.Cyan(cmdutils.GetLocationText(rule.AccountId, rule.Region)) + "]")
+		}
+	}
+	if !noBackup {
+		options.BackupDir = backupDir
+	}
+
+	resultCh := make(chan utils.Result[coreTypes.ResourceRef])
+	var err error
+	if applyFile != "" {
+		var plan *core.RemovalPlan
+		plan, err = core.ReadRemovalPlan(applyFile, core.SecurityGroupsPlan)
+		if err == nil {
+			err = core.ApplyRemovalPlanAsync(cmd.Context(), plan, options, scope, resultCh)
+		}
+	} else {
+		err = core.RemoveSecurityGroupsAsync(cmd.Context(), *sg, filters, options, scope, resultCh)
+	}
 	if err != nil {
 		pterm.Error.Println(err)
 		return
@@ -49,17 +92,60 @@ func runRemove(cmd *cobra.Command, args []string) {
 		if res.Err != nil {
 			pterm.Error.Println(res.Err)
 		} else {
-			pterm.Info.Println("Removed Security Group with ID of " + pterm.LightGreen(res.Data))
+			pterm.Info.Println("Removed Security Group with ID of " + pterm.LightGreen(res.Data.Id) + " [" +
+				pterm.Cyan(cmdutils.GetLocationText(res.Data.AccountId, res.Data.Region)) + "]")
 		}
 	}
 }
 
+func runPlan(cmd *cobra.Command) {
+	plan, err := core.PlanSecurityGroupsRemoval(cmd.Context(), *sg, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	if err := core.WriteRemovalPlan(planFile, plan); err != nil {
+		pterm.Error.Println(err)
+		return
+	}
+
+	cmdutils.PrintRemovalPlan(plan, "Security Group")
+	pterm.Info.Printf("Plan written to %s. Run `sg-ripper remove --apply %s` to apply it.\n", planFile, planFile)
+}
+
 func init() {
 	includeValidateFlags(Cmd)
 }
 
 func includeValidateFlags(cmd *cobra.Command) {
 	sg = cmd.Flags().StringSlice("sg", nil,
-		"Security Group Id to be deleted. It can accept multiple values divided by comma. "+
-			"Default: none")
+		"Security Group Id to be deleted. It can accept multiple values divided by comma. If none is specified, "+
+			"every unused Security Group matching the filters is deleted. Default: none")
+	cmd.Flags().StringVar(&planFile, "plan", "",
+		"[Optional] Write the Security Groups which would be removed into a plan file instead of removing them. "+
+			"If no Security Group ID is provided, every unused Security Group matching the filters is planned for "+
+			"removal.")
+	cmd.Flags().StringVar(&applyFile, "apply", "",
+		"[Optional] Remove the Security Groups from a plan file which did not change since planning.")
+	cmd.Flags().BoolVar(&force, "force", false,
+		"[Optional] Skip the usage check and attempt to remove the Security Groups provided with --sg even if they "+
+			"are in use.")
+	cmd.Flags().BoolVar(&cascade, "cascade", false,
+		"[Optional] Revoke the rules of other Security Groups referencing the Security Groups to be removed, then "+
+			"remove them in the order of their dependencies. Every revoked rule is reported and backed up into the "+
+			"backup directory, so it can be restored with the restore command.")
+	cmd.Flags().StringVar(&backupDir, "backup-dir", cmdutils.DefaultBackupDir(),
+		"[Optional] Directory in which the Security Groups are backed up before being removed. The backups can be "+
+			"used with the restore command.")
+	cmd.Flags().BoolVar(&noBackup, "no-backup", false,
+		"[Optional] Do not back up the Security Groups before removing them.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+	cmd.MarkFlagsMutuallyExclusive("plan", "apply")
+	cmd.MarkFlagsMutuallyExclusive("backup-dir", "no-backup")
+	cmd.MarkFlagsMutuallyExclusive("force", "plan")
+	cmd.MarkFlagsMutuallyExclusive("force", "apply")
+	cmd.MarkFlagsMutuallyExclusive("sg", "apply")
+	cmd.MarkFlagsMutuallyExclusive("cascade", "plan")
+	cmd.MarkFlagsMutuallyExclusive("cascade", "apply")
 }
@@ -2,7 +2,9 @@ package removeeni
 
 import (
 	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
 	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -14,53 +16,100 @@ var (
 		Short: "Remove unused Elastic Network Interfaces.",
 		RunE:  runRemoveENI,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			regionFlag := cmd.Flags().Lookup("region")
-			if regionFlag != nil {
-				region = regionFlag.Value.String()
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
 			}
 
-			profileFlag := cmd.Flags().Lookup("profile")
-			if profileFlag != nil {
-				profile = profileFlag.Value.String()
+			if len(*eni) <= 0 && planFile == "" && applyFile == "" && !filterFlags.IsSet() {
+				return fmt.Errorf("no Network Interface ID or filter provided")
 			}
 
-			if len(*eni) <= 0 {
-				return fmt.Errorf("no Security Group ID provided")
+			if applyFile != "" && filterFlags.IsSet() {
+				return fmt.Errorf("filters cannot be used together with --apply")
+			}
+
+			filters, err = filterFlags.ToFilters(core.All)
+			if err != nil {
+				return err
 			}
 
 			return nil
 		},
 	}
 
-	eni     *[]string
-	region  string
-	profile string
+	eni         *[]string
+	scope       core.Scope
+	planFile    string
+	applyFile   string
+	filters     core.Filters
+	filterFlags cmdutils.FilterFlags
 )
 
 func runRemoveENI(cmd *cobra.Command, args []string) error {
-	resultCh := make(chan utils.Result[string])
-	err := core.RemoveENIAsync(cmd.Context(), *eni, region, profile, resultCh)
-	if err != nil {
-		return err
+	if planFile != "" {
+		return runPlan(cmd)
+	}
+
+	resultCh := make(chan utils.Result[coreTypes.ResourceRef])
+	if applyFile != "" {
+		plan, err := core.ReadRemovalPlan(applyFile, core.NetworkInterfacesPlan)
+		if err != nil {
+			return err
+		}
+		if err := core.ApplyRemovalPlanAsync(cmd.Context(), plan, core.RemoveOptions{}, scope, resultCh); err != nil {
+			return err
+		}
+	} else {
+		if err := core.RemoveENIAsync(cmd.Context(), *eni, filters, scope, resultCh); err != nil {
+			return err
+		}
 	}
 
 	for res := range resultCh {
 		if res.Err != nil {
 			pterm.Error.Println(res.Err)
 		} else {
-			pterm.Info.Println("Removed Elastic Network Interface with ID of " + pterm.LightGreen(res.Data))
+			pterm.Info.Println("Removed Elastic Network Interface with ID of " + pterm.LightGreen(res.Data.Id) + " [" +
+				pterm.Cyan(cmdutils.GetLocationText(res.Data.AccountId, res.Data.Region)) + "]")
 		}
 	}
 
 	return nil
 }
 
+func runPlan(cmd *cobra.Command) error {
+	plan, err := core.PlanNetworkInterfacesRemoval(cmd.Context(), *eni, filters, scope)
+	if err := cmdutils.ReportScanError(err); err != nil {
+		return err
+	}
+
+	if err := core.WriteRemovalPlan(planFile, plan); err != nil {
+		return err
+	}
+
+	cmdutils.PrintRemovalPlan(plan, "Elastic Network Interface")
+	pterm.Info.Printf("Plan written to %s. Run `sg-ripper remove-eni --apply %s` to apply it.\n", planFile, planFile)
+
+	return nil
+}
+
 func init() {
 	includeValidateFlags(Cmd)
 }
 
 func includeValidateFlags(cmd *cobra.Command) {
 	eni = cmd.Flags().StringSlice("eni", nil,
-		"Network Interface ID to be deleted. It can accept multiple values divided by comma. "+
-			"Default: none")
+		"Network Interface ID to be deleted. It can accept multiple values divided by comma. If none is specified, "+
+			"every unused Network Interface matching the filters is deleted. Default: none")
+	cmd.Flags().StringVar(&planFile, "plan", "",
+		"[Optional] Write the Network Interfaces which would be removed into a plan file instead of removing them. "+
+			"If no Network Interface ID is provided, every unused Network Interface matching the filters is planned "+
+			"for removal.")
+	cmd.Flags().StringVar(&applyFile, "apply", "",
+		"[Optional] Remove the Network Interfaces from a plan file which did not change since planning.")
+	cmdutils.IncludeFilterFlags(cmd, &filterFlags)
+	cmd.MarkFlagsMutuallyExclusive("plan", "apply")
+	cmd.MarkFlagsMutuallyExclusive("eni", "apply")
 }
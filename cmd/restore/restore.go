@@ -0,0 +1,112 @@
+package restore
+
+import (
+	"fmt"
+	"github.com/cloud-crafts/sg-ripper/cmd/cmdutils"
+	"github.com/cloud-crafts/sg-ripper/pkg/core"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	Cmd = &cobra.Command{
+		Use:   "restore",
+		Short: "Restore removed Security Groups from their backup.",
+		RunE:  runRestore,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			scope, err = cmdutils.GetScope(cmd)
+			if err != nil {
+				return err
+			}
+
+			if len(*sg) <= 0 && len(*files) <= 0 && len(*revokedRulesFiles) <= 0 {
+				return fmt.Errorf("no Security Group ID or backup file provided")
+			}
+
+			if len(*sg) > 0 && backupDir == "" {
+				return fmt.Errorf("no backup directory provided")
+			}
+
+			return nil
+		},
+	}
+
+	sg                *[]string
+	files             *[]string
+	revokedRulesFiles *[]string
+	backupDir         string
+	scope             core.Scope
+)
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	backupFiles := make([]string, 0, len(*sg)+len(*files))
+	for _, id := range *sg {
+		backupFile, err := core.FindLatestSecurityGroupBackup(backupDir, id)
+		if err != nil {
+			return err
+		}
+		backupFiles = append(backupFiles, backupFile)
+	}
+	backupFiles = append(backupFiles, *files...)
+
+	// The rules revoked by a cascading removal reference the restored Security Groups by their new ID
+	restoredIds := make(map[string]string, len(backupFiles))
+	for _, backupFile := range backupFiles {
+		backup, err := core.ReadSecurityGroupBackup(backupFile)
+		if err != nil {
+			pterm.Error.Println(err)
+			continue
+		}
+
+		newGroupId, err := core.RestoreSecurityGroup(cmd.Context(), backup, scope)
+		if err != nil {
+			pterm.Error.Println(err)
+			continue
+		}
+		restoredIds[backup.GroupId] = newGroupId
+
+		pterm.Info.Printf("Restored Security Group %s (%s) with ID of %s\n", backup.Name, backup.GroupId,
+			pterm.LightGreen(newGroupId))
+	}
+
+	for _, revokedRulesFile := range *revokedRulesFiles {
+		backup, err := core.ReadRevokedRulesBackup(revokedRulesFile)
+		if err != nil {
+			pterm.Error.Println(err)
+			continue
+		}
+
+		errs, err := core.RestoreRevokedRules(cmd.Context(), backup, restoredIds, scope)
+		if err != nil {
+			pterm.Error.Println(err)
+			continue
+		}
+		for _, err := range errs {
+			pterm.Error.Println(err)
+		}
+
+		pterm.Info.Printf("Restored the revoked rules from %s [%s]\n", revokedRulesFile,
+			pterm.Cyan(cmdutils.GetLocationText(backup.AccountId, backup.Region)))
+	}
+
+	return nil
+}
+
+func init() {
+	includeValidateFlags(Cmd)
+}
+
+func includeValidateFlags(cmd *cobra.Command) {
+	sg = cmd.Flags().StringSlice("sg", nil,
+		"[Optional] ID of the removed Security Group to be restored from its latest backup. It can accept multiple "+
+			"values divided by comma.")
+	files = cmd.Flags().StringSlice("file", nil,
+		"[Optional] Backup file to be restored. It can accept multiple values divided by comma.")
+	revokedRulesFiles = cmd.Flags().StringSlice("revoked-rules", nil,
+		"[Optional] Backup file of the rules revoked by a cascading removal to be authorized again. The rules "+
+			"referencing Security Groups restored by the same command use their new ID. It can accept multiple values "+
+			"divided by comma.")
+	cmd.Flags().StringVar(&backupDir, "backup-dir", cmdutils.DefaultBackupDir(),
+		"[Optional] Directory in which the backups of the removed Security Groups are stored.")
+}
@@ -0,0 +1,192 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Checks reported by Audit
+const (
+	// CheckOpenAllPorts is an inbound rule allowing every protocol, or every port of a protocol, from the internet. Only
+	// the rules allowing every protocol are critical
+	CheckOpenAllPorts = "open-all-ports"
+	// CheckOpenSensitivePort is an inbound rule allowing a sensitive port from the internet
+	CheckOpenSensitivePort = "open-sensitive-port"
+	// CheckOpenIngress is an inbound rule allowing other ports from the internet
+	CheckOpenIngress = "open-ingress"
+	// CheckAllPorts is an inbound rule allowing every protocol, or every port of a protocol, from a source other than
+	// the internet
+	CheckAllPorts = "all-ports"
+	// CheckUnrestrictedEgress is an outbound rule allowing traffic to the internet, reported only if forbidden
+	CheckUnrestrictedEgress = "unrestricted-egress"
+	// CheckDefaultGroupRules is a default Security Group with rules, which should restrict all traffic instead
+	CheckDefaultGroupRules = "default-group-with-rules"
+)
+
+// DefaultSensitivePorts are the ports of remote administration, file sharing, databases, caches and container
+// management services, which must not be reachable from the internet
+var DefaultSensitivePorts = []int32{20, 21, 22, 23, 25, 135, 137, 138, 139, 445, 1433, 1521, 2049, 2375, 2376, 2379,
+	3306, 3389, 5432, 5601, 5900, 5984, 6379, 9042, 9200, 9300, 11211, 27017}
+
+// AuditOptions controls which rules are reported by Audit
+type AuditOptions struct {
+	// SensitivePorts are the ports which must not be open to the internet. If it is empty, DefaultSensitivePorts is used
+	SensitivePorts []int32
+	// ForbidUnrestrictedEgress reports the outbound rules allowing traffic to the internet
+	ForbidUnrestrictedEgress bool
+}
+
+var severityRanks = map[string]int{
+	coreTypes.SeverityCritical: 4,
+	coreTypes.SeverityHigh:     3,
+	coreTypes.SeverityMedium:   2,
+	coreTypes.SeverityLow:      1,
+}
+
+// ValidateSeverity returns an error if the severity provided is not supported
+func ValidateSeverity(severity string) error {
+	if _, ok := severityRanks[severity]; !ok {
+		return fmt.Errorf("unsupported severity %q, expected one of: %s, %s, %s, %s", severity,
+			coreTypes.SeverityCritical, coreTypes.SeverityHigh, coreTypes.SeverityMedium, coreTypes.SeverityLow)
+	}
+	return nil
+}
+
+// IsAtLeast returns true if the severity is the same as or higher than the minimum severity
+func IsAtLeast(severity string, minimum string) bool {
+	return severityRanks[severity] >= severityRanks[minimum]
+}
+
+// Audit analyzes the rules of the Security Groups and reports the risky ones. The findings are ordered by their
+// severity, the findings of Security Groups in use being reported first for the same severity
+func Audit(groups []coreTypes.SecurityGroupDetails, options AuditOptions) []coreTypes.AuditFinding {
+	sensitivePorts := options.SensitivePorts
+	if len(sensitivePorts) == 0 {
+		sensitivePorts = DefaultSensitivePorts
+	}
+
+	findings := make([]coreTypes.AuditFinding, 0)
+	for _, sg := range groups {
+		newFinding := newFindingFactory(sg)
+
+		if sg.Default && len(sg.Rules) > 0 {
+			findings = append(findings, newFinding(CheckDefaultGroupRules, coreTypes.SeverityMedium, "",
+				fmt.Sprintf("Default Security Group has %d rule(s), it should restrict all traffic", len(sg.Rules))))
+		}
+
+		for _, rule := range sg.Rules {
+			switch {
+			case rule.IsEgress:
+				if options.ForbidUnrestrictedEgress && isInternet(rule) {
+					findings = append(findings, newFinding(CheckUnrestrictedEgress, coreTypes.SeverityMedium, rule.Id,
+						fmt.Sprintf("Outbound rule allows %s to %s", getTrafficText(rule), getPeerText(rule))))
+				}
+			case isInternet(rule) && rule.Protocol == allProtocols:
+				findings = append(findings, newFinding(CheckOpenAllPorts, coreTypes.SeverityCritical, rule.Id,
+					fmt.Sprintf("Inbound rule allows %s from %s", getTrafficText(rule), getPeerText(rule))))
+			case isInternet(rule) && isAllPorts(rule):
+				findings = append(findings, newFinding(CheckOpenAllPorts, coreTypes.SeverityHigh, rule.Id,
+					fmt.Sprintf("Inbound rule allows every %s port from %s", rule.Protocol, getPeerText(rule))))
+			case isInternet(rule):
+				openPorts := make([]string, 0)
+				for _, port := range sensitivePorts {
+					if coversPort(rule, port) {
+						openPorts = append(openPorts, fmt.Sprintf("%d", port))
+					}
+				}
+				if len(openPorts) > 0 {
+					findings = append(findings, newFinding(CheckOpenSensitivePort, coreTypes.SeverityHigh, rule.Id,
+						fmt.Sprintf("Inbound rule allows sensitive port(s) %s from %s", strings.Join(openPorts, ", "),
+							getPeerText(rule))))
+				} else {
+					findings = append(findings, newFinding(CheckOpenIngress, coreTypes.SeverityLow, rule.Id,
+						fmt.Sprintf("Inbound rule allows %s from %s", getTrafficText(rule), getPeerText(rule))))
+				}
+			case isAllPorts(rule) && aws.ToString(rule.ReferencedGroupId) != sg.Id:
+				findings = append(findings, newFinding(CheckAllPorts, coreTypes.SeverityMedium, rule.Id,
+					fmt.Sprintf("Inbound rule allows %s from %s", getTrafficText(rule), getPeerText(rule))))
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.InUse != b.InUse {
+			return a.InUse
+		}
+		if a.AccountId != b.AccountId {
+			return a.AccountId < b.AccountId
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.SecurityGroupId != b.SecurityGroupId {
+			return a.SecurityGroupId < b.SecurityGroupId
+		}
+		return a.RuleId < b.RuleId
+	})
+	return findings
+}
+
+// Return a function creating the findings of a Security Group, together with the Network Interfaces and the resources
+// using it
+func newFindingFactory(sg coreTypes.SecurityGroupDetails) func(check string, severity string, ruleId string,
+	message string) coreTypes.AuditFinding {
+	networkInterfaces := make([]string, 0, len(sg.UsedBy))
+	for _, eni := range sg.UsedBy {
+		networkInterfaces = append(networkInterfaces, eni.Id)
+	}
+
+	resources := make([]string, 0)
+	for _, eni := range sg.UsedBy {
+		for _, owner := range eni.Owners() {
+			if !slices.Contains(resources, owner.Label) {
+				resources = append(resources, owner.Label)
+			}
+		}
+	}
+	for _, reference := range sg.ConfigurationReferences {
+		if label := reference.Label(); !slices.Contains(resources, label) {
+			resources = append(resources, label)
+		}
+	}
+
+	return func(check string, severity string, ruleId string, message string) coreTypes.AuditFinding {
+		return coreTypes.AuditFinding{
+			Check:             check,
+			Severity:          severity,
+			Message:           message,
+			SecurityGroupId:   sg.Id,
+			SecurityGroupName: sg.Name,
+			VpcId:             sg.VpcId,
+			AccountId:         sg.AccountId,
+			Region:            sg.Region,
+			RuleId:            ruleId,
+			InUse:             len(sg.UsedBy) > 0 || len(sg.ConfigurationReferences) > 0,
+			NetworkInterfaces: networkInterfaces,
+			Resources:         resources,
+		}
+	}
+}
+
+func isInternet(rule coreTypes.SecurityGroupRule) bool {
+	return aws.ToString(rule.CidrIpv4) == "0.0.0.0/0" || aws.ToString(rule.CidrIpv6) == "::/0"
+}
+
+func isAllPorts(rule coreTypes.SecurityGroupRule) bool {
+	return rule.Protocol == allProtocols || (isPortProtocol(rule.Protocol) && rule.FromPort <= 0 && rule.ToPort >= 65535)
+}
+
+func coversPort(rule coreTypes.SecurityGroupRule, port int32) bool {
+	if rule.Protocol == allProtocols {
+		return true
+	}
+	return isPortProtocol(rule.Protocol) && rule.FromPort <= port && port <= rule.ToPort
+}
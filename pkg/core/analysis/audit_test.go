@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	type finding struct {
+		check    string
+		severity string
+		ruleId   string
+	}
+	isDefault := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.Name = "default"
+		sg.Default = true
+		return sg
+	}
+
+	tests := []struct {
+		name     string
+		rule     coreTypes.SecurityGroupRule
+		options  AuditOptions
+		findings []finding
+	}{
+		{
+			name:     "every protocol from the internet",
+			rule:     newTestRule("r1", allProtocols, -1, -1, "0.0.0.0/0"),
+			findings: []finding{{CheckOpenAllPorts, coreTypes.SeverityCritical, "r1"}},
+		},
+		{
+			name:     "every TCP port from the internet",
+			rule:     newTestRule("r1", "tcp", 0, 65535, "0.0.0.0/0"),
+			findings: []finding{{CheckOpenAllPorts, coreTypes.SeverityHigh, "r1"}},
+		},
+		{
+			name:     "every UDP port from the IPv6 internet",
+			rule:     newTestRule("r1", "udp", 0, 65535, "::/0"),
+			findings: []finding{{CheckOpenAllPorts, coreTypes.SeverityHigh, "r1"}},
+		},
+		{
+			name:     "sensitive port from the internet",
+			rule:     newTestRule("r1", "tcp", 22, 22, "0.0.0.0/0"),
+			findings: []finding{{CheckOpenSensitivePort, coreTypes.SeverityHigh, "r1"}},
+		},
+		{
+			name:     "range covering a sensitive port from the internet",
+			rule:     newTestRule("r1", "tcp", 3000, 3500, "::/0"),
+			findings: []finding{{CheckOpenSensitivePort, coreTypes.SeverityHigh, "r1"}},
+		},
+		{
+			name:     "custom sensitive port from the internet",
+			rule:     newTestRule("r1", "tcp", 8080, 8080, "0.0.0.0/0"),
+			options:  AuditOptions{SensitivePorts: []int32{8080}},
+			findings: []finding{{CheckOpenSensitivePort, coreTypes.SeverityHigh, "r1"}},
+		},
+		{
+			name:     "other port from the internet",
+			rule:     newTestRule("r1", "tcp", 443, 443, "0.0.0.0/0"),
+			findings: []finding{{CheckOpenIngress, coreTypes.SeverityLow, "r1"}},
+		},
+		{
+			name:     "every protocol from a private network",
+			rule:     newTestRule("r1", allProtocols, -1, -1, "10.0.0.0/8"),
+			findings: []finding{{CheckAllPorts, coreTypes.SeverityMedium, "r1"}},
+		},
+		{
+			name:     "every TCP port from another group",
+			rule:     newTestRule("r1", "tcp", 0, 65535, "sg-b"),
+			findings: []finding{{CheckAllPorts, coreTypes.SeverityMedium, "r1"}},
+		},
+		{
+			name: "every protocol from the group itself",
+			rule: newTestRule("r1", allProtocols, -1, -1, "sg-a"),
+		},
+		{
+			name: "sensitive port from a private network",
+			rule: newTestRule("r1", "tcp", 22, 22, "10.0.0.0/8"),
+		},
+		{
+			name: "egress to the internet",
+			rule: egress(newTestRule("r1", allProtocols, -1, -1, "0.0.0.0/0")),
+		},
+		{
+			name:     "forbidden egress to the internet",
+			rule:     egress(newTestRule("r1", allProtocols, -1, -1, "0.0.0.0/0")),
+			options:  AuditOptions{ForbidUnrestrictedEgress: true},
+			findings: []finding{{CheckUnrestrictedEgress, coreTypes.SeverityMedium, "r1"}},
+		},
+		{
+			name:    "forbidden egress to a private network",
+			rule:    egress(newTestRule("r1", allProtocols, -1, -1, "10.0.0.0/8")),
+			options: AuditOptions{ForbidUnrestrictedEgress: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := make([]finding, 0)
+			for _, f := range Audit([]coreTypes.SecurityGroupDetails{newGroupWithRules("sg-a", "vpc-1", tt.rule)},
+				tt.options) {
+				findings = append(findings, finding{f.Check, f.Severity, f.RuleId})
+			}
+			require.ElementsMatch(t, tt.findings, findings)
+		})
+	}
+
+	t.Run("default group with rules", func(t *testing.T) {
+		sg := isDefault(newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", allProtocols, -1, -1, "sg-a"),
+			egress(newTestRule("r2", allProtocols, -1, -1, "0.0.0.0/0"))))
+		findings := Audit([]coreTypes.SecurityGroupDetails{sg}, AuditOptions{})
+		require.Len(t, findings, 1)
+		require.Equal(t, CheckDefaultGroupRules, findings[0].Check)
+		require.Equal(t, coreTypes.SeverityMedium, findings[0].Severity)
+		require.Empty(t, findings[0].RuleId)
+	})
+
+	t.Run("default group without rules", func(t *testing.T) {
+		sg := isDefault(newGroupWithRules("sg-a", "vpc-1"))
+		require.Empty(t, Audit([]coreTypes.SecurityGroupDetails{sg}, AuditOptions{}))
+	})
+}
+
+func TestAuditOrder(t *testing.T) {
+	inUse := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.UsedBy = []coreTypes.NetworkInterfaceDetails{{Id: "eni-1"}}
+		return sg
+	}
+	groups := []coreTypes.SecurityGroupDetails{
+		newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 443, 443, "0.0.0.0/0")),
+		newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "tcp", 22, 22, "0.0.0.0/0")),
+		inUse(newGroupWithRules("sg-c", "vpc-1", newTestRule("r3", "tcp", 3389, 3389, "0.0.0.0/0"))),
+		newGroupWithRules("sg-d", "vpc-1", newTestRule("r4", allProtocols, -1, -1, "0.0.0.0/0"),
+			newTestRule("r5", allProtocols, -1, -1, "10.0.0.0/8")),
+	}
+
+	ruleIds := make([]string, 0)
+	for _, finding := range Audit(groups, AuditOptions{}) {
+		ruleIds = append(ruleIds, finding.RuleId)
+	}
+	require.Equal(t, []string{"r4", "r3", "r2", "r5", "r1"}, ruleIds)
+}
+
+func TestIsAtLeast(t *testing.T) {
+	require.True(t, IsAtLeast(coreTypes.SeverityCritical, coreTypes.SeverityHigh))
+	require.True(t, IsAtLeast(coreTypes.SeverityHigh, coreTypes.SeverityHigh))
+	require.False(t, IsAtLeast(coreTypes.SeverityMedium, coreTypes.SeverityHigh))
+	require.Error(t, ValidateSeverity("urgent"))
+	require.NoError(t, ValidateSeverity(coreTypes.SeverityLow))
+}
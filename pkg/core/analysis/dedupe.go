@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"sort"
+	"strings"
+)
+
+// Security Groups of the same VPC which allow the same traffic
+type equivalenceClass struct {
+	accountId   string
+	region      string
+	vpcId       string
+	permissions []permission
+	members     []coreTypes.DedupeMember
+}
+
+func (c *equivalenceClass) location() string {
+	return strings.Join([]string{c.accountId, c.region, c.vpcId}, "|")
+}
+
+// Dedupe clusters the Security Groups of each VPC which allow the same traffic, and finds the Security Groups which
+// allow a strict subset of the traffic allowed by other Security Groups of the same VPC. The rules are normalized before
+// being compared, so rules written differently but allowing the same traffic are equivalent. Default Security Groups
+// are ignored, since they cannot be consolidated, and Security Groups without rules are never reported as a subset
+func Dedupe(groups []coreTypes.SecurityGroupDetails) coreTypes.DedupeReport {
+	classes := make([]*equivalenceClass, 0)
+	classesByKey := make(map[string]*equivalenceClass)
+	for _, sg := range groups {
+		if sg.Default {
+			continue
+		}
+
+		permissions := getPermissions(sg)
+		keys := make([]string, 0, len(permissions))
+		for _, p := range permissions {
+			keys = append(keys, p.key())
+		}
+		key := strings.Join([]string{sg.AccountId, sg.Region, sg.VpcId, strings.Join(keys, ";")}, "|")
+
+		class, ok := classesByKey[key]
+		if !ok {
+			class = &equivalenceClass{
+				accountId:   sg.AccountId,
+				region:      sg.Region,
+				vpcId:       sg.VpcId,
+				permissions: permissions,
+				members:     make([]coreTypes.DedupeMember, 0),
+			}
+			classesByKey[key] = class
+			classes = append(classes, class)
+		}
+		class.members = append(class.members, newDedupeMember(sg))
+	}
+
+	// Rules can allow the same traffic without being equal, for example when one of them has rules covered by others
+	merged := make([]*equivalenceClass, 0, len(classes))
+	for _, class := range classes {
+		isMerged := false
+		for _, other := range merged {
+			if other.location() == class.location() && coversAll(other.permissions, class.permissions) &&
+				coversAll(class.permissions, other.permissions) {
+				other.members = append(other.members, class.members...)
+				isMerged = true
+				break
+			}
+		}
+		if !isMerged {
+			merged = append(merged, class)
+		}
+	}
+
+	for _, class := range merged {
+		sort.Slice(class.members, func(i, j int) bool {
+			return class.members[i].Id < class.members[j].Id
+		})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].location() != merged[j].location() {
+			return merged[i].location() < merged[j].location()
+		}
+		return merged[i].members[0].Id < merged[j].members[0].Id
+	})
+
+	report := coreTypes.DedupeReport{
+		Duplicates: make([]coreTypes.DuplicateSecurityGroups, 0),
+		Subsets:    make([]coreTypes.SubsetSecurityGroups, 0),
+	}
+	for _, class := range merged {
+		if len(class.members) > 1 {
+			report.Duplicates = append(report.Duplicates, coreTypes.DuplicateSecurityGroups{
+				AccountId:      class.accountId,
+				Region:         class.region,
+				VpcId:          class.vpcId,
+				SecurityGroups: class.members,
+			})
+		}
+	}
+	for _, subset := range merged {
+		if len(subset.permissions) == 0 {
+			continue
+		}
+		for _, superset := range merged {
+			if subset == superset || subset.location() != superset.location() {
+				continue
+			}
+			if coversAll(superset.permissions, subset.permissions) {
+				report.Subsets = append(report.Subsets, coreTypes.SubsetSecurityGroups{
+					AccountId: subset.accountId,
+					Region:    subset.region,
+					VpcId:     subset.vpcId,
+					Subset:    subset.members,
+					Superset:  superset.members,
+				})
+			}
+		}
+	}
+	return report
+}
+
+func newDedupeMember(sg coreTypes.SecurityGroupDetails) coreTypes.DedupeMember {
+	usedBy := make([]string, 0, len(sg.UsedBy))
+	for _, eni := range sg.UsedBy {
+		usedBy = append(usedBy, eni.Id)
+	}
+	return coreTypes.DedupeMember{
+		Id:        sg.Id,
+		Name:      sg.Name,
+		RuleCount: len(sg.Rules),
+		UsedBy:    usedBy,
+	}
+}
+
+// Check if every permission of the subset is covered by a permission of the superset
+func coversAll(superset []permission, subset []permission) bool {
+	for _, p := range subset {
+		isCovered := false
+		for _, q := range superset {
+			if q.covers(p) {
+				isCovered = true
+				break
+			}
+		}
+		if !isCovered {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package analysis
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func getMemberIds(members []coreTypes.DedupeMember) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Id)
+	}
+	return ids
+}
+
+func TestDedupe(t *testing.T) {
+	type subset struct {
+		subset   []string
+		superset []string
+	}
+	isDefault := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.Default = true
+		return sg
+	}
+
+	tests := []struct {
+		name       string
+		groups     []coreTypes.SecurityGroupDetails
+		duplicates [][]string
+		subsets    []subset
+	}{
+		{
+			name: "same rules",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8")),
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r2", "6", 443, 443, "10.0.0.0/8")),
+			},
+			duplicates: [][]string{{"sg-a", "sg-b"}},
+		},
+		{
+			name: "rules written differently",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 80, 90, "10.0.0.0/8")),
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "tcp", 80, 84, "10.0.0.0/8"),
+					newTestRule("r3", "tcp", 85, 90, "10.0.0.0/8"), newTestRule("r4", "tcp", 80, 80, "10.1.0.0/16")),
+			},
+			duplicates: [][]string{{"sg-a", "sg-b"}},
+		},
+		{
+			name: "different VPCs",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8")),
+				newGroupWithRules("sg-b", "vpc-2", newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8")),
+			},
+		},
+		{
+			name: "self-references are not duplicates",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "-1", 0, 0, "sg-a")),
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "-1", 0, 0, "sg-b")),
+			},
+		},
+		{
+			name: "references to the same group",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "-1", 0, 0, "sg-c")),
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "-1", 0, 0, "sg-c")),
+			},
+			duplicates: [][]string{{"sg-a", "sg-b"}},
+		},
+		{
+			name: "default groups are ignored",
+			groups: []coreTypes.SecurityGroupDetails{
+				isDefault(newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8"))),
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8")),
+				newGroupWithRules("sg-c", "vpc-1", newTestRule("r3", "tcp", 22, 22, "10.0.0.0/8")),
+			},
+		},
+		{
+			name: "strict subset",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8")),
+				newGroupWithRules("sg-b", "vpc-1", newTestRule("r2", "tcp", 443, 443, "10.1.0.0/16")),
+				newGroupWithRules("sg-c", "vpc-1", newTestRule("r3", "tcp", 443, 443, "192.168.0.0/16")),
+			},
+			subsets: []subset{{subset: []string{"sg-b"}, superset: []string{"sg-a"}}},
+		},
+		{
+			name: "groups without rules are not a subset",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8")),
+				newGroupWithRules("sg-b", "vpc-1"),
+			},
+		},
+		{
+			name: "subset of another VPC",
+			groups: []coreTypes.SecurityGroupDetails{
+				newGroupWithRules("sg-a", "vpc-1", newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8")),
+				newGroupWithRules("sg-b", "vpc-2", newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8")),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Dedupe(tt.groups)
+
+			duplicates := make([][]string, 0)
+			for _, d := range report.Duplicates {
+				duplicates = append(duplicates, getMemberIds(d.SecurityGroups))
+			}
+			subsets := make([]subset, 0)
+			for _, s := range report.Subsets {
+				subsets = append(subsets, subset{subset: getMemberIds(s.Subset), superset: getMemberIds(s.Superset)})
+			}
+
+			if tt.duplicates == nil {
+				tt.duplicates = [][]string{}
+			}
+			if tt.subsets == nil {
+				tt.subsets = []subset{}
+			}
+			require.Equal(t, tt.duplicates, duplicates)
+			require.Equal(t, tt.subsets, subsets)
+		})
+	}
+}
+
+func TestCoversAll(t *testing.T) {
+	toPermissions := func(rules ...coreTypes.SecurityGroupRule) []permission {
+		return getPermissions(coreTypes.SecurityGroupDetails{Id: "sg-a", Rules: rules})
+	}
+
+	tests := []struct {
+		name     string
+		superset []permission
+		subset   []permission
+		covers   bool
+	}{
+		{
+			name:     "empty subset",
+			superset: toPermissions(),
+			subset:   toPermissions(),
+			covers:   true,
+		},
+		{
+			name: "every permission is covered",
+			superset: toPermissions(newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8"),
+				newTestRule("r2", "udp", 53, 53, "::/0")),
+			subset: toPermissions(newTestRule("r3", "tcp", 22, 22, "10.1.0.0/16"),
+				newTestRule("r4", "udp", 53, 53, "2001:db8::/32")),
+			covers: true,
+		},
+		{
+			name: "covered by merged port ranges",
+			superset: toPermissions(newTestRule("r1", "tcp", 80, 84, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 85, 90, "10.0.0.0/8")),
+			subset: toPermissions(newTestRule("r3", "tcp", 82, 88, "10.0.0.0/8")),
+			covers: true,
+		},
+		{
+			name:     "one permission is not covered",
+			superset: toPermissions(newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8")),
+			subset: toPermissions(newTestRule("r2", "tcp", 22, 22, "10.0.0.0/8"),
+				newTestRule("r3", "udp", 53, 53, "10.0.0.0/8")),
+			covers: false,
+		},
+		{
+			name:     "empty superset",
+			superset: toPermissions(),
+			subset:   toPermissions(newTestRule("r1", "tcp", 22, 22, "10.0.0.0/8")),
+			covers:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.covers, coversAll(tt.superset, tt.subset))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"net"
+	"sort"
+)
+
+// Checks reported by FindRedundantRules
+const (
+	// CheckRedundantRule is a rule allowing only traffic which is already allowed by another rule of the Security Group
+	CheckRedundantRule = "redundant-rule"
+	// CheckMissingReference is a rule referencing a Security Group which does not exist anymore
+	CheckMissingReference = "missing-reference"
+)
+
+// RuleContext holds the information about the peers of the rules which is not part of the Security Groups
+type RuleContext struct {
+	// PrefixLists maps the ID of a managed prefix list to its CIDR blocks
+	PrefixLists map[string][]string
+	// MissingSecurityGroups are the IDs of the referenced Security Groups which do not exist anymore
+	MissingSecurityGroups map[string]bool
+}
+
+// FindRedundantRules reports the rules of every Security Group which are fully covered by another rule of the same
+// Security Group, for example a CIDR block contained in a wider one, a port range inside a wider one or a CIDR block
+// which is also an entry of a prefix list. When two rules allow exactly the same traffic, only one of them is reported.
+// Rules referencing Security Groups which do not exist anymore are reported as well
+func FindRedundantRules(groups []coreTypes.SecurityGroupDetails, ruleContext RuleContext) []coreTypes.RuleFinding {
+	findings := make([]coreTypes.RuleFinding, 0)
+	for _, sg := range groups {
+		permissions := make([][]permission, len(sg.Rules))
+		for i, rule := range sg.Rules {
+			permissions[i] = getRulePermissions(rule, ruleContext.PrefixLists)
+		}
+
+		newFinding := func(check string, rule coreTypes.SecurityGroupRule, message string) coreTypes.RuleFinding {
+			return coreTypes.RuleFinding{
+				Check:             check,
+				Message:           message,
+				SecurityGroupId:   sg.Id,
+				SecurityGroupName: sg.Name,
+				VpcId:             sg.VpcId,
+				AccountId:         sg.AccountId,
+				Region:            sg.Region,
+				RuleCount:         len(sg.Rules),
+				Rule:              rule,
+			}
+		}
+
+		for i, rule := range sg.Rules {
+			if ruleContext.isMissingReference(rule) {
+				findings = append(findings, newFinding(CheckMissingReference, rule,
+					fmt.Sprintf("%s references the Security Group %s, which does not exist anymore",
+						getRuleText(rule), aws.ToString(rule.ReferencedGroupId))))
+				continue
+			}
+
+			for j, other := range sg.Rules {
+				if i == j || ruleContext.isMissingReference(other) || !coversAll(permissions[j], permissions[i]) {
+					continue
+				}
+				// Rules allowing exactly the same traffic cover each other, only the first one of them is kept
+				if coversAll(permissions[i], permissions[j]) && rule.Id < other.Id {
+					continue
+				}
+
+				finding := newFinding(CheckRedundantRule, rule, fmt.Sprintf("%s is covered by rule %s allowing %s %s %s",
+					getRuleText(rule), other.Id, getTrafficText(other), getDirectionPreposition(other),
+					getPeerText(other)))
+				finding.CoveredBy = other.Id
+				findings = append(findings, finding)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.AccountId != b.AccountId {
+			return a.AccountId < b.AccountId
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.SecurityGroupId != b.SecurityGroupId {
+			return a.SecurityGroupId < b.SecurityGroupId
+		}
+		return a.Rule.Id < b.Rule.Id
+	})
+	return findings
+}
+
+func (c RuleContext) isMissingReference(rule coreTypes.SecurityGroupRule) bool {
+	return rule.ReferencedGroupId != nil && c.MissingSecurityGroups[*rule.ReferencedGroupId]
+}
+
+// Normalize a rule. A rule referencing a prefix list is expanded to the CIDR blocks of the prefix list if they are known
+func getRulePermissions(rule coreTypes.SecurityGroupRule, prefixLists map[string][]string) []permission {
+	p := newPermission(rule)
+	if rule.PrefixListId == nil || len(prefixLists[*rule.PrefixListId]) == 0 {
+		return []permission{p}
+	}
+
+	permissions := make([]permission, 0, len(prefixLists[*rule.PrefixListId]))
+	for _, cidr := range prefixLists[*rule.PrefixListId] {
+		entry := p
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			entry.network = network
+			cidr = network.String()
+		}
+		entry.peer = "cidr:" + cidr
+		permissions = append(permissions, entry)
+	}
+	return permissions
+}
+
+// Get the direction, the traffic and the peer of a rule
+func getRuleText(rule coreTypes.SecurityGroupRule) string {
+	direction := "Inbound"
+	if rule.IsEgress {
+		direction = "Outbound"
+	}
+	return fmt.Sprintf("%s rule %s allowing %s %s %s", direction, rule.Id, getTrafficText(rule),
+		getDirectionPreposition(rule), getPeerText(rule))
+}
+
+func getDirectionPreposition(rule coreTypes.SecurityGroupRule) string {
+	if rule.IsEgress {
+		return "to"
+	}
+	return "from"
+}
@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFindRedundantRules(t *testing.T) {
+	type finding struct {
+		check     string
+		ruleId    string
+		coveredBy string
+	}
+
+	tests := []struct {
+		name        string
+		rules       []coreTypes.SecurityGroupRule
+		ruleContext RuleContext
+		findings    []finding
+	}{
+		{
+			name: "CIDR block contained in a wider one",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "10.1.0.0/16"),
+				newTestRule("sgr-2", "tcp", 443, 443, "10.0.0.0/8"),
+			},
+			findings: []finding{{CheckRedundantRule, "sgr-1", "sgr-2"}},
+		},
+		{
+			name: "port range inside a wider one",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 0, 65535, "10.0.0.0/8"),
+				newTestRule("sgr-2", "tcp", 22, 22, "10.0.0.0/8"),
+			},
+			findings: []finding{{CheckRedundantRule, "sgr-2", "sgr-1"}},
+		},
+		{
+			name: "identical rules keep the smaller rule ID",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-2", "tcp", 443, 443, "10.0.0.0/8"),
+				newTestRule("sgr-1", "6", 443, 443, "10.0.0.0/8"),
+			},
+			findings: []finding{{CheckRedundantRule, "sgr-2", "sgr-1"}},
+		},
+		{
+			name: "CIDR block which is an entry of a prefix list",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "10.1.0.0/16"),
+				newTestRule("sgr-2", "tcp", 443, 443, "pl-1"),
+			},
+			ruleContext: RuleContext{PrefixLists: map[string][]string{"pl-1": {"192.168.0.0/16", "10.0.0.0/8"}}},
+			findings:    []finding{{CheckRedundantRule, "sgr-1", "sgr-2"}},
+		},
+		{
+			name: "prefix list covered only partially",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "10.0.0.0/8"),
+				newTestRule("sgr-2", "tcp", 443, 443, "pl-1"),
+			},
+			ruleContext: RuleContext{PrefixLists: map[string][]string{"pl-1": {"192.168.0.0/16", "10.1.0.0/16"}}},
+		},
+		{
+			name: "unknown prefix list",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "10.1.0.0/16"),
+				newTestRule("sgr-2", "tcp", 443, 443, "pl-1"),
+			},
+		},
+		{
+			name: "empty prefix list",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "10.1.0.0/16"),
+				newTestRule("sgr-2", "tcp", 443, 443, "pl-1"),
+			},
+			ruleContext: RuleContext{PrefixLists: map[string][]string{"pl-1": {}}},
+		},
+		{
+			name: "unknown prefix list referenced by a wider rule",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "pl-1"),
+				newTestRule("sgr-2", "tcp", 0, 65535, "pl-1"),
+			},
+			findings: []finding{{CheckRedundantRule, "sgr-1", "sgr-2"}},
+		},
+		{
+			name: "different directions",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "-1", 0, 0, "0.0.0.0/0"),
+				egress(newTestRule("sgr-2", "tcp", 443, 443, "10.0.0.0/8")),
+			},
+		},
+		{
+			name: "missing reference",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("sgr-1", "tcp", 443, 443, "sg-x"),
+				newTestRule("sgr-2", "tcp", 0, 65535, "sg-x"),
+			},
+			ruleContext: RuleContext{MissingSecurityGroups: map[string]bool{"sg-x": true}},
+			findings: []finding{
+				{CheckMissingReference, "sgr-1", ""},
+				{CheckMissingReference, "sgr-2", ""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := make([]finding, 0)
+			for _, f := range FindRedundantRules([]coreTypes.SecurityGroupDetails{newGroupWithRules("sg-a", "vpc-1", tt.rules...)},
+				tt.ruleContext) {
+				findings = append(findings, finding{f.Check, f.Rule.Id, f.CoveredBy})
+			}
+
+			if tt.findings == nil {
+				tt.findings = []finding{}
+			}
+			require.Equal(t, tt.findings, findings)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"net"
+	"sort"
+	"strings"
+)
+
+// The protocol of the rules allowing every protocol and every port
+const allProtocols = "-1"
+
+// Check if the ports of a rule are meaningful for its protocol. The protocol is either a name or a number
+func isPortProtocol(protocol string) bool {
+	switch protocol {
+	case "tcp", "udp", "6", "17":
+		return true
+	default:
+		return false
+	}
+}
+
+// Get the protocol and the ports allowed by a rule
+func getTrafficText(rule coreTypes.SecurityGroupRule) string {
+	protocol := rule.Protocol
+	if name, ok := protocolNames[protocol]; ok {
+		protocol = name
+	}
+
+	switch {
+	case protocol == allProtocols:
+		return "all traffic"
+	case !isPortProtocol(protocol):
+		return fmt.Sprintf("protocol %s", protocol)
+	case rule.FromPort == rule.ToPort:
+		return fmt.Sprintf("%s port %d", protocol, rule.FromPort)
+	default:
+		return fmt.Sprintf("%s ports %d-%d", protocol, rule.FromPort, rule.ToPort)
+	}
+}
+
+// Get the source of an inbound rule or the destination of an outbound rule
+func getPeerText(rule coreTypes.SecurityGroupRule) string {
+	switch {
+	case rule.CidrIpv4 != nil:
+		return *rule.CidrIpv4
+	case rule.CidrIpv6 != nil:
+		return *rule.CidrIpv6
+	case rule.PrefixListId != nil:
+		return *rule.PrefixListId
+	default:
+		return aws.ToString(rule.ReferencedGroupId)
+	}
+}
+
+// A rule reduced to the traffic it allows, so that rules can be compared regardless of how they were written: protocol
+// numbers are replaced by their names and CIDR blocks are canonical. References to Security Groups keep the referenced
+// group ID, so two Security Groups which each allow only their own members do not allow the same traffic
+type permission struct {
+	isEgress bool
+	protocol string
+	fromPort int32
+	toPort   int32
+	peer     string
+	network  *net.IPNet
+}
+
+var protocolNames = map[string]string{
+	"1":  "icmp",
+	"6":  "tcp",
+	"17": "udp",
+	"58": "icmpv6",
+}
+
+// Normalize a rule of a Security Group
+func newPermission(rule coreTypes.SecurityGroupRule) permission {
+	p := permission{
+		isEgress: rule.IsEgress,
+		protocol: strings.ToLower(rule.Protocol),
+		fromPort: rule.FromPort,
+		toPort:   rule.ToPort,
+	}
+	if name, ok := protocolNames[p.protocol]; ok {
+		p.protocol = name
+	}
+	if p.protocol == allProtocols {
+		p.fromPort, p.toPort = -1, -1
+	}
+
+	switch {
+	case rule.CidrIpv4 != nil || rule.CidrIpv6 != nil:
+		cidr := aws.ToString(rule.CidrIpv4)
+		if rule.CidrIpv6 != nil {
+			cidr = *rule.CidrIpv6
+		}
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			p.network = network
+			cidr = network.String()
+		}
+		p.peer = "cidr:" + cidr
+	case rule.PrefixListId != nil:
+		p.peer = "prefix-list:" + *rule.PrefixListId
+	default:
+		p.peer = "sg:" + aws.ToString(rule.ReferencedGroupId)
+	}
+	return p
+}
+
+// Get a key which is the same for the permissions allowing the same traffic
+func (p permission) key() string {
+	return fmt.Sprintf("%t|%s|%d|%d|%s", p.isEgress, p.protocol, p.fromPort, p.toPort, p.peer)
+}
+
+// Check if every packet allowed by the other permission is also allowed by this permission
+func (p permission) covers(other permission) bool {
+	if p.isEgress != other.isEgress {
+		return false
+	}
+
+	switch {
+	case p.protocol == allProtocols:
+	case p.protocol != other.protocol:
+		return false
+	case isPortProtocol(p.protocol):
+		if p.fromPort > other.fromPort || other.toPort > p.toPort {
+			return false
+		}
+	case p.fromPort != -1 && (p.fromPort != other.fromPort || (p.toPort != -1 && p.toPort != other.toPort)):
+		// The ports of ICMP are the type and the code, -1 meaning any of them
+		return false
+	}
+
+	if p.peer == other.peer {
+		return true
+	}
+	if p.network == nil || other.network == nil || len(p.network.IP) != len(other.network.IP) {
+		return false
+	}
+	pOnes, _ := p.network.Mask.Size()
+	otherOnes, _ := other.network.Mask.Size()
+	return pOnes <= otherOnes && p.network.Contains(other.network.IP)
+}
+
+// Normalize the rules of a Security Group and merge the overlapping and adjacent port ranges allowed for the same
+// protocol and peer. The permissions are sorted by their keys
+func getPermissions(sg coreTypes.SecurityGroupDetails) []permission {
+	permissions := make([]permission, 0, len(sg.Rules))
+	for _, rule := range sg.Rules {
+		permissions = append(permissions, newPermission(rule))
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		a, b := permissions[i], permissions[j]
+		if a.isEgress != b.isEgress {
+			return !a.isEgress
+		}
+		if a.protocol != b.protocol {
+			return a.protocol < b.protocol
+		}
+		if a.peer != b.peer {
+			return a.peer < b.peer
+		}
+		if a.fromPort != b.fromPort {
+			return a.fromPort < b.fromPort
+		}
+		return a.toPort < b.toPort
+	})
+
+	merged := make([]permission, 0, len(permissions))
+	for _, p := range permissions {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if last.isEgress == p.isEgress && last.protocol == p.protocol && last.peer == p.peer {
+				if isPortProtocol(p.protocol) && p.fromPort <= last.toPort+1 {
+					last.toPort = max(last.toPort, p.toPort)
+					continue
+				}
+				if last.key() == p.key() {
+					continue
+				}
+			}
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
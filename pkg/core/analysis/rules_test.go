@@ -0,0 +1,265 @@
+package analysis
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// Create an inbound rule. The peer is a Security Group ID, a prefix list ID, an IPv6 or an IPv4 CIDR block
+func newTestRule(id string, protocol string, fromPort int32, toPort int32, peer string) coreTypes.SecurityGroupRule {
+	rule := coreTypes.SecurityGroupRule{Id: id, Protocol: protocol, FromPort: fromPort, ToPort: toPort}
+	switch {
+	case strings.HasPrefix(peer, "sg-"):
+		rule.ReferencedGroupId = aws.String(peer)
+	case strings.HasPrefix(peer, "pl-"):
+		rule.PrefixListId = aws.String(peer)
+	case strings.Contains(peer, ":"):
+		rule.CidrIpv6 = aws.String(peer)
+	default:
+		rule.CidrIpv4 = aws.String(peer)
+	}
+	return rule
+}
+
+func egress(rule coreTypes.SecurityGroupRule) coreTypes.SecurityGroupRule {
+	rule.IsEgress = true
+	return rule
+}
+
+// Create a Security Group with the rules provided, which is the only input of the analyses
+func newGroupWithRules(id string, vpcId string, rules ...coreTypes.SecurityGroupRule) coreTypes.SecurityGroupDetails {
+	return coreTypes.SecurityGroupDetails{Id: id, Name: id, VpcId: vpcId, Rules: rules}
+}
+
+func TestPermissionCovers(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   coreTypes.SecurityGroupRule
+		other  coreTypes.SecurityGroupRule
+		covers bool
+	}{
+		{
+			name:   "wider CIDR block",
+			rule:   newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8"),
+			other:  newTestRule("r2", "tcp", 443, 443, "10.1.2.0/24"),
+			covers: true,
+		},
+		{
+			name:   "narrower CIDR block",
+			rule:   newTestRule("r1", "tcp", 443, 443, "10.1.2.0/24"),
+			other:  newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "disjoint CIDR blocks",
+			rule:   newTestRule("r1", "tcp", 443, 443, "10.0.0.0/16"),
+			other:  newTestRule("r2", "tcp", 443, 443, "10.1.0.0/16"),
+			covers: false,
+		},
+		{
+			name:   "non canonical CIDR block",
+			rule:   newTestRule("r1", "tcp", 443, 443, "10.0.0.1/8"),
+			other:  newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8"),
+			covers: true,
+		},
+		{
+			name:   "IPv4 does not cover IPv6",
+			rule:   newTestRule("r1", "tcp", 443, 443, "0.0.0.0/0"),
+			other:  newTestRule("r2", "tcp", 443, 443, "::/0"),
+			covers: false,
+		},
+		{
+			name:   "IPv6 does not cover IPv4",
+			rule:   newTestRule("r1", "tcp", 443, 443, "::/0"),
+			other:  newTestRule("r2", "tcp", 443, 443, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "wider IPv6 CIDR block",
+			rule:   newTestRule("r1", "tcp", 443, 443, "2001:db8::/32"),
+			other:  newTestRule("r2", "tcp", 443, 443, "2001:db8:1::/48"),
+			covers: true,
+		},
+		{
+			name:   "wider port range",
+			rule:   newTestRule("r1", "tcp", 1000, 2000, "10.0.0.0/8"),
+			other:  newTestRule("r2", "tcp", 1500, 1600, "10.0.0.0/8"),
+			covers: true,
+		},
+		{
+			name:   "overlapping port range",
+			rule:   newTestRule("r1", "tcp", 1000, 2000, "10.0.0.0/8"),
+			other:  newTestRule("r2", "tcp", 1500, 2500, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "protocol number and name",
+			rule:   newTestRule("r1", "6", 22, 22, "10.0.0.0/8"),
+			other:  newTestRule("r2", "tcp", 22, 22, "10.0.0.0/8"),
+			covers: true,
+		},
+		{
+			name:   "different protocols",
+			rule:   newTestRule("r1", "tcp", 53, 53, "10.0.0.0/8"),
+			other:  newTestRule("r2", "udp", 53, 53, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "all protocols cover any protocol",
+			rule:   newTestRule("r1", "-1", 0, 0, "10.0.0.0/8"),
+			other:  newTestRule("r2", "udp", 53, 53, "10.1.0.0/16"),
+			covers: true,
+		},
+		{
+			name:   "all protocols to a different peer",
+			rule:   newTestRule("r1", "-1", 0, 0, "10.0.0.0/8"),
+			other:  newTestRule("r2", "udp", 53, 53, "192.168.0.0/16"),
+			covers: false,
+		},
+		{
+			name:   "every port does not cover all protocols",
+			rule:   newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8"),
+			other:  newTestRule("r2", "-1", -1, -1, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "any ICMP type covers a type",
+			rule:   newTestRule("r1", "icmp", -1, -1, "10.0.0.0/8"),
+			other:  newTestRule("r2", "1", 8, 0, "10.0.0.0/8"),
+			covers: true,
+		},
+		{
+			name:   "any ICMP code covers a code",
+			rule:   newTestRule("r1", "icmp", 3, -1, "10.0.0.0/8"),
+			other:  newTestRule("r2", "icmp", 3, 4, "10.0.0.0/8"),
+			covers: true,
+		},
+		{
+			name:   "ICMP types are not ranges",
+			rule:   newTestRule("r1", "icmp", 0, 8, "10.0.0.0/8"),
+			other:  newTestRule("r2", "icmp", 3, 4, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "ICMP code does not cover any code",
+			rule:   newTestRule("r1", "icmp", 3, 4, "10.0.0.0/8"),
+			other:  newTestRule("r2", "icmp", 3, -1, "10.0.0.0/8"),
+			covers: false,
+		},
+		{
+			name:   "same Security Group",
+			rule:   newTestRule("r1", "tcp", 0, 65535, "sg-a"),
+			other:  newTestRule("r2", "tcp", 80, 80, "sg-a"),
+			covers: true,
+		},
+		{
+			name:   "different Security Groups",
+			rule:   newTestRule("r1", "tcp", 0, 65535, "sg-a"),
+			other:  newTestRule("r2", "tcp", 80, 80, "sg-b"),
+			covers: false,
+		},
+		{
+			name:   "inbound does not cover outbound",
+			rule:   newTestRule("r1", "-1", 0, 0, "0.0.0.0/0"),
+			other:  egress(newTestRule("r2", "tcp", 80, 80, "10.0.0.0/8")),
+			covers: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.covers, newPermission(tt.rule).covers(newPermission(tt.other)))
+		})
+	}
+}
+
+func TestGetPermissionsMergesPortRanges(t *testing.T) {
+	type portRange struct {
+		from int32
+		to   int32
+	}
+
+	tests := []struct {
+		name   string
+		rules  []coreTypes.SecurityGroupRule
+		ranges []portRange
+	}{
+		{
+			name: "overlapping ranges",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "tcp", 1000, 2000, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 1500, 2500, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{1000, 2500}},
+		},
+		{
+			name: "adjacent ranges",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "tcp", 81, 90, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 80, 80, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{80, 90}},
+		},
+		{
+			name: "contained range",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "tcp", 0, 65535, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 22, 22, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{0, 65535}},
+		},
+		{
+			name: "separate ranges",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "tcp", 443, 443, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 80, 80, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{80, 80}, {443, 443}},
+		},
+		{
+			name: "different peers are not merged",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "tcp", 80, 80, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 81, 81, "192.168.0.0/16"),
+			},
+			ranges: []portRange{{80, 80}, {81, 81}},
+		},
+		{
+			name: "protocol numbers are merged with names",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "6", 80, 80, "10.0.0.0/8"),
+				newTestRule("r2", "tcp", 81, 81, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{80, 81}},
+		},
+		{
+			name: "ICMP types are not merged",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "icmp", 3, -1, "10.0.0.0/8"),
+				newTestRule("r2", "icmp", 4, -1, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{3, -1}, {4, -1}},
+		},
+		{
+			name: "identical ICMP rules are merged",
+			rules: []coreTypes.SecurityGroupRule{
+				newTestRule("r1", "icmp", 8, 0, "10.0.0.0/8"),
+				newTestRule("r2", "1", 8, 0, "10.0.0.0/8"),
+			},
+			ranges: []portRange{{8, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges := make([]portRange, 0)
+			for _, p := range getPermissions(coreTypes.SecurityGroupDetails{Id: "sg-a", Rules: tt.rules}) {
+				ranges = append(ranges, portRange{p.fromPort, p.toPort})
+			}
+			require.Equal(t, tt.ranges, ranges)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupVersion is the version of the backup file format
+const BackupVersion = 1
+
+// The timestamp format used in the backup file names. It is chosen to be sortable lexicographically
+const backupTimestampFormat = "20060102T150405Z"
+
+// SecurityGroupBackup contains everything needed for recreating a removed Security Group
+type SecurityGroupBackup struct {
+	Version     int                           `json:"version"`
+	CreatedAt   time.Time                     `json:"createdAt"`
+	AccountId   string                        `json:"accountId,omitempty"`
+	Region      string                        `json:"region"`
+	GroupId     string                        `json:"groupId"`
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	VpcId       string                        `json:"vpcId"`
+	Tags        map[string]string             `json:"tags"`
+	Rules       []coreTypes.SecurityGroupRule `json:"rules"`
+}
+
+// RevokedRulesBackup contains the rules of other Security Groups revoked by a cascading removal in an account and a
+// region, so that they can be authorized again
+type RevokedRulesBackup struct {
+	Version   int                           `json:"version"`
+	CreatedAt time.Time                     `json:"createdAt"`
+	AccountId string                        `json:"accountId,omitempty"`
+	Region    string                        `json:"region"`
+	Rules     []coreTypes.SecurityGroupRule `json:"rules"`
+}
+
+// DefaultBackupDir returns the directory in which the Security Groups are backed up before removal if no other
+// directory is specified
+func DefaultBackupDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sg-ripper", "backups"), nil
+}
+
+// ReadSecurityGroupBackup loads a Security Group backup from a file
+func ReadSecurityGroupBackup(path string) (*SecurityGroupBackup, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var backup SecurityGroupBackup
+	if err := json.Unmarshal(content, &backup); err != nil {
+		return nil, fmt.Errorf("invalid backup file %s: %w", path, err)
+	}
+
+	if backup.Version != BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d, expected %d", backup.Version, BackupVersion)
+	}
+
+	return &backup, nil
+}
+
+// ReadRevokedRulesBackup loads the backup of the rules revoked by a cascading removal from a file
+func ReadRevokedRulesBackup(path string) (*RevokedRulesBackup, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var backup RevokedRulesBackup
+	if err := json.Unmarshal(content, &backup); err != nil {
+		return nil, fmt.Errorf("invalid backup file %s: %w", path, err)
+	}
+
+	if backup.Version != BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version %d, expected %d", backup.Version, BackupVersion)
+	}
+
+	return &backup, nil
+}
+
+// FindLatestSecurityGroupBackup returns the path of the most recent backup of a Security Group from the backup directory
+func FindLatestSecurityGroupBackup(backupDir string, securityGroupId string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(backupDir, securityGroupId+"-*.json"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no backup found for Security Group %s in %s", securityGroupId, backupDir)
+	}
+
+	sort.Strings(matches)
+	return matches[len(matches)-1], nil
+}
+
+// RestoreSecurityGroup recreates a Security Group with its tags and rules from a backup in the account and the region
+// where the backup was taken. The profile and the role name are taken from the scope, its accounts and regions are
+// ignored. Returns the ID of the new Security Group. References of the Security Group to itself are replaced with the
+// new ID, other referenced Security Groups must still exist.
+func RestoreSecurityGroup(ctx context.Context, backup *SecurityGroupBackup, scope Scope) (string, error) {
+	backupScope := Scope{Profile: scope.Profile, RoleName: scope.RoleName, Regions: []string{backup.Region}}
+	if backup.AccountId != "" {
+		backupScope.Accounts = []string{backup.AccountId}
+	}
+
+	targets, err := resolveTargets(ctx, backupScope)
+	if err != nil {
+		return "", err
+	}
+
+	ec2Client := clients.NewAwsEc2Client(targets[0].cfg)
+
+	// Tags with the "aws:" prefix are reserved, they cannot be set by the user
+	tags := make(map[string]string, len(backup.Tags))
+	for key, value := range backup.Tags {
+		if !strings.HasPrefix(key, "aws:") {
+			tags[key] = value
+		}
+	}
+
+	newGroupId, err := ec2Client.CreateSecurityGroup(ctx, backup.Name, backup.Description, backup.VpcId, tags)
+	if err != nil {
+		return "", err
+	}
+
+	// Every new Security Group gets an outbound rule allowing all traffic. It is removed, since the backup contains
+	// the original outbound rules
+	defaultRules, err := ec2Client.DescribeSecurityGroupRulesByGroupIds(ctx, []string{newGroupId})
+	if err != nil {
+		return newGroupId, fmt.Errorf("the Security Group %s was created, but its rules could not be restored: %w",
+			newGroupId, err)
+	}
+
+	defaultEgressRuleIds := make([]string, 0)
+	for _, rule := range defaultRules {
+		if aws.ToBool(rule.IsEgress) {
+			defaultEgressRuleIds = append(defaultEgressRuleIds, *rule.SecurityGroupRuleId)
+		}
+	}
+
+	if err := ec2Client.RevokeSecurityGroupRules(ctx, newGroupId, nil, defaultEgressRuleIds); err != nil {
+		return newGroupId, fmt.Errorf("the Security Group %s was created, but its rules could not be restored: %w",
+			newGroupId, err)
+	}
+
+	ingress := make([]ec2Types.IpPermission, 0)
+	egress := make([]ec2Types.IpPermission, 0)
+	for _, rule := range backup.Rules {
+		permission := toIpPermission(rule, map[string]string{backup.GroupId: newGroupId})
+		if rule.IsEgress {
+			egress = append(egress, permission)
+		} else {
+			ingress = append(ingress, permission)
+		}
+	}
+
+	if err := ec2Client.AuthorizeSecurityGroupRules(ctx, newGroupId, ingress, egress); err != nil {
+		return newGroupId, fmt.Errorf("the Security Group %s was created, but its rules could not be restored: %w",
+			newGroupId, err)
+	}
+
+	return newGroupId, nil
+}
+
+// RestoreRevokedRules authorizes again the rules revoked by a cascading removal, in the account and the region where
+// they were revoked. The profile and the role name are taken from the scope, its accounts and regions are ignored.
+// Security Groups recreated from their backup are provided as a map from their original ID to their new ID: their own
+// rules are skipped, since they were restored together with the Security Group, and the rules referencing them are
+// authorized with the new ID. Returns an error for each Security Group whose rules could not be authorized
+func RestoreRevokedRules(ctx context.Context, backup *RevokedRulesBackup, restoredIds map[string]string,
+	scope Scope) ([]error, error) {
+	backupScope := Scope{Profile: scope.Profile, RoleName: scope.RoleName, Regions: []string{backup.Region}}
+	if backup.AccountId != "" {
+		backupScope.Accounts = []string{backup.AccountId}
+	}
+
+	targets, err := resolveTargets(ctx, backupScope)
+	if err != nil {
+		return nil, err
+	}
+
+	ec2Client := clients.NewAwsEc2Client(targets[0].cfg)
+
+	ingressByGroup := make(map[string][]ec2Types.IpPermission)
+	egressByGroup := make(map[string][]ec2Types.IpPermission)
+	sgIds := make([]string, 0)
+	for _, rule := range backup.Rules {
+		if _, ok := restoredIds[rule.GroupId]; ok {
+			continue
+		}
+		if _, ok := ingressByGroup[rule.GroupId]; !ok {
+			sgIds = append(sgIds, rule.GroupId)
+			ingressByGroup[rule.GroupId] = make([]ec2Types.IpPermission, 0)
+		}
+
+		permission := toIpPermission(rule, restoredIds)
+		if rule.IsEgress {
+			egressByGroup[rule.GroupId] = append(egressByGroup[rule.GroupId], permission)
+		} else {
+			ingressByGroup[rule.GroupId] = append(ingressByGroup[rule.GroupId], permission)
+		}
+	}
+
+	errs := make([]error, 0)
+	for _, sgId := range sgIds {
+		if err := ec2Client.AuthorizeSecurityGroupRules(ctx, sgId, ingressByGroup[sgId], egressByGroup[sgId]); err != nil {
+			errs = append(errs, fmt.Errorf("the revoked rules of Security Group %s could not be restored: %w", sgId,
+				err))
+		}
+	}
+	return errs, nil
+}
+
+// Write a backup file for each Security Group into the backup directory. Returns the IDs of the Security Groups which
+// were backed up and an error for each Security Group which could not be backed up
+func backupSecurityGroups(ctx context.Context, t target, securityGroupIds []string, backupDir string) ([]string, []error, error) {
+	if len(securityGroupIds) == 0 {
+		return securityGroupIds, nil, nil
+	}
+
+	if err := os.MkdirAll(backupDir, 0o700); err != nil {
+		return nil, nil, err
+	}
+
+	ec2Client := clients.NewAwsEc2Client(t.cfg)
+
+	rules, err := ec2Client.DescribeSecurityGroupRulesByGroupIds(ctx, securityGroupIds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rulesByGroup := make(map[string][]coreTypes.SecurityGroupRule)
+	for _, rule := range toSecurityGroupRules(rules) {
+		rulesByGroup[rule.GroupId] = append(rulesByGroup[rule.GroupId], rule)
+	}
+
+	sgResultCh := make(chan utils.Result[[]ec2Types.SecurityGroup])
+	ec2Client.DescribeSecurityGroups(ctx, securityGroupIds, sgResultCh)
+
+	createdAt := time.Now().UTC()
+	backedUp := make([]string, 0, len(securityGroupIds))
+	errs := make([]error, 0)
+	for sgResult := range sgResultCh {
+		if sgResult.Err != nil {
+			return nil, nil, sgResult.Err
+		}
+		for _, sg := range sgResult.Data {
+			groupRules := rulesByGroup[*sg.GroupId]
+			if groupRules == nil {
+				groupRules = make([]coreTypes.SecurityGroupRule, 0)
+			}
+
+			backup := SecurityGroupBackup{
+				Version:     BackupVersion,
+				CreatedAt:   createdAt,
+				AccountId:   t.accountId,
+				Region:      t.region(),
+				GroupId:     *sg.GroupId,
+				Name:        aws.ToString(sg.GroupName),
+				Description: aws.ToString(sg.Description),
+				VpcId:       aws.ToString(sg.VpcId),
+				Tags:        utils.TagsToMap(sg.Tags),
+				Rules:       groupRules,
+			}
+
+			if err := writeSecurityGroupBackup(backupDir, backup); err != nil {
+				errs = append(errs, fmt.Errorf("not removing Security Group %s, the backup failed: %w", backup.GroupId, err))
+				continue
+			}
+			backedUp = append(backedUp, backup.GroupId)
+		}
+	}
+
+	return backedUp, errs, nil
+}
+
+// Write a backup file with the rules which are going to be revoked by a cascading removal into the backup directory
+func backupRevokedRules(t target, rules []coreTypes.SecurityGroupRule, backupDir string) error {
+	if err := os.MkdirAll(backupDir, 0o700); err != nil {
+		return err
+	}
+
+	backup := RevokedRulesBackup{
+		Version:   BackupVersion,
+		CreatedAt: time.Now().UTC(),
+		AccountId: t.accountId,
+		Region:    t.region(),
+		Rules:     rules,
+	}
+
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("revoked-rules-%s-%s-%s.json", backup.AccountId, backup.Region,
+		backup.CreatedAt.Format(backupTimestampFormat))
+	return os.WriteFile(filepath.Join(backupDir, fileName), content, 0o600)
+}
+
+func writeSecurityGroupBackup(backupDir string, backup SecurityGroupBackup) error {
+	content, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s-%s.json", backup.GroupId, backup.CreatedAt.Format(backupTimestampFormat))
+	return os.WriteFile(filepath.Join(backupDir, fileName), content, 0o600)
+}
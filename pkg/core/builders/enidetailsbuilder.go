@@ -4,29 +4,24 @@ import (
 	"context"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
-	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
 	cmap "github.com/orcaman/concurrent-map/v2"
+	"sync"
 )
 
 type EniDetailsBuilder struct {
-	awsEc2Client    *clients.AwsEc2Client
-	awsLambdaClient *clients.AwsLambdaClient
-	awsElbClient    *clients.AwsElbClient
-	awsEcsClient    *clients.AwsEcsClient
-	awsRdsClient    *clients.AwsRdsClient
-	cache           cmap.ConcurrentMap[string, *coreTypes.NetworkInterfaceDetails]
+	region    string
+	resolvers []namedResolver
+	cache     cmap.ConcurrentMap[string, *coreTypes.NetworkInterfaceDetails]
 }
 
+// NewEniBuilder creates a new EniDetailsBuilder which uses every AttachmentResolver registered at the time of the call
 func NewEniBuilder(cfg aws.Config) *EniDetailsBuilder {
 	return &EniDetailsBuilder{
-		awsEc2Client:    clients.NewAwsEc2Client(cfg),
-		awsLambdaClient: clients.NewAwsLambdaClient(cfg),
-		awsElbClient:    clients.NewAwsElbClient(cfg),
-		awsEcsClient:    clients.NewAwsEcsClient(cfg),
-		awsRdsClient:    clients.NewAwsRdsClient(cfg),
-		cache:           cmap.New[*coreTypes.NetworkInterfaceDetails](),
+		region:    cfg.Region,
+		resolvers: newResolvers(cfg),
+		cache:     cmap.New[*coreTypes.NetworkInterfaceDetails](),
 	}
 }
 
@@ -42,40 +37,7 @@ func (e *EniDetailsBuilder) FromRemoteInterfaces(ctx context.Context, awsEniBatc
 			if cachedEni, ok := e.cache.Get(*awsEni.NetworkInterfaceId); ok {
 				eniDetails = append(eniDetails, *cachedEni)
 			} else {
-				resultCh := make(chan utils.Result[any])
-
-				asyncFetchers := []func(context.Context, ec2Types.NetworkInterface, chan utils.Result[any]){
-					e.getLambdaAttachmentAsync, e.getEcsAttachmentAsync, e.getElbAttachmentAsync, e.getVpcAttachmentAsync,
-					e.getRdsAttachmentAsync,
-				}
-
-				for _, fn := range asyncFetchers {
-					go fn(ctx, awsEni, resultCh)
-				}
-
-				var lambdaAttachment *coreTypes.LambdaAttachment
-				var ecsAttachment *coreTypes.EcsAttachment
-				var elbAttachment *coreTypes.ElbAttachment
-				var vpceAttachment *coreTypes.VpceAttachment
-				var rdsAttachments []coreTypes.RdsAttachment
-				for range asyncFetchers {
-					res := <-resultCh
-					if res.Err != nil {
-						return nil, res.Err
-					}
-					switch res.Data.(type) {
-					case *coreTypes.LambdaAttachment:
-						lambdaAttachment = res.Data.(*coreTypes.LambdaAttachment)
-					case *coreTypes.EcsAttachment:
-						ecsAttachment = res.Data.(*coreTypes.EcsAttachment)
-					case *coreTypes.ElbAttachment:
-						elbAttachment = res.Data.(*coreTypes.ElbAttachment)
-					case *coreTypes.VpceAttachment:
-						vpceAttachment = res.Data.(*coreTypes.VpceAttachment)
-					case []coreTypes.RdsAttachment:
-						rdsAttachments = res.Data.([]coreTypes.RdsAttachment)
-					}
-				}
+				attachments := e.resolveAttachments(ctx, awsEni)
 
 				sgIdentifiers := make([]coreTypes.SecurityGroupIdentifier, 0)
 				for _, group := range awsEni.Groups {
@@ -102,21 +64,24 @@ func (e *EniDetailsBuilder) FromRemoteInterfaces(ctx context.Context, awsEniBatc
 
 				newEni := coreTypes.NetworkInterfaceDetails{
 					Id:                          *awsEni.NetworkInterfaceId,
+					AccountId:                   aws.ToString(awsEni.OwnerId),
+					Region:                      e.region,
 					Description:                 awsEni.Description,
 					Type:                        string(awsEni.InterfaceType),
 					ManagedByAWS:                *awsEni.RequesterManaged,
 					Status:                      string(awsEni.Status),
+					VpcId:                       aws.ToString(awsEni.VpcId),
+					SubnetId:                    aws.ToString(awsEni.SubnetId),
+					Tags:                        utils.TagsToMap(awsEni.TagSet),
 					PrivateIPAddress:            primaryIPAddress,
 					SecondaryPrivateIPAddresses: secondaryPrivateIPAddresses,
-					EC2Attachment:               getEC2Attachment(awsEni),
-					LambdaAttachment:            lambdaAttachment,
-					ECSAttachment:               ecsAttachment,
-					ELBAttachment:               elbAttachment,
-					VPCEAttachment:              vpceAttachment,
-					RDSAttachments:              rdsAttachments,
 					SecurityGroupIdentifiers:    sgIdentifiers,
 				}
 
+				for _, attachment := range attachments {
+					attachment.AttachTo(&newEni)
+				}
+
 				// Add the new interface to the cache
 				e.cache.Set(newEni.Id, &newEni)
 				eniDetails = append(eniDetails, newEni)
@@ -127,65 +92,33 @@ func (e *EniDetailsBuilder) FromRemoteInterfaces(ctx context.Context, awsEniBatc
 	return eniDetails, nil
 }
 
-func (e *EniDetailsBuilder) getLambdaAttachmentAsync(ctx context.Context, awsEni ec2Types.NetworkInterface, resultCh chan utils.Result[any]) {
-	lambdaAttachment, err := e.awsLambdaClient.GetLambdaAttachment(ctx, awsEni)
-	if err != nil {
-		resultCh <- utils.Result[any]{Err: err}
-		return
-	}
-	resultCh <- utils.Result[any]{
-		Data: lambdaAttachment,
-	}
-}
-
-func (e *EniDetailsBuilder) getEcsAttachmentAsync(ctx context.Context, awsEni ec2Types.NetworkInterface, resultCh chan utils.Result[any]) {
-	ecsAttachment, err := e.awsEcsClient.GetEcsAttachment(ctx, awsEni)
-	if err != nil {
-		resultCh <- utils.Result[any]{Err: err}
-		return
-	}
-	resultCh <- utils.Result[any]{
-		Data: ecsAttachment,
-	}
-}
-
-func (e *EniDetailsBuilder) getElbAttachmentAsync(ctx context.Context, awsEni ec2Types.NetworkInterface, resultCh chan utils.Result[any]) {
-	elbAttachment, err := e.awsElbClient.GetELBAttachment(ctx, awsEni)
-	if err != nil {
-		resultCh <- utils.Result[any]{Err: err}
-		return
-	}
-	resultCh <- utils.Result[any]{
-		Data: elbAttachment,
-	}
-}
-
-func (e *EniDetailsBuilder) getVpcAttachmentAsync(ctx context.Context, awsEni ec2Types.NetworkInterface, resultCh chan utils.Result[any]) {
-	vpceAttachment, err := e.awsEc2Client.GetVpceAttachment(ctx, awsEni)
-	if err != nil {
-		resultCh <- utils.Result[any]{Err: err}
-		return
-	}
-	resultCh <- utils.Result[any]{
-		Data: vpceAttachment,
+// Fan out the network interface to every resolver and collect the attachments found, in the order in which the
+// resolvers were registered. A resolver which fails does not prevent the other ones from resolving the network
+// interface, it is recorded as an unresolved attachment instead
+func (e *EniDetailsBuilder) resolveAttachments(ctx context.Context, awsEni ec2Types.NetworkInterface) []coreTypes.Attachment {
+	results := make([]coreTypes.Attachment, len(e.resolvers))
+
+	var wg sync.WaitGroup
+	for i, resolver := range e.resolvers {
+		i, resolver := i, resolver // capture values
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attachment, err := resolver.resolver.Resolve(ctx, awsEni)
+			if err != nil {
+				attachment = &coreTypes.UnresolvedAttachment{Resolver: resolver.name, Error: err.Error()}
+			}
+			results[i] = attachment
+		}()
 	}
-}
+	wg.Wait()
 
-func (e *EniDetailsBuilder) getRdsAttachmentAsync(ctx context.Context, awsEni ec2Types.NetworkInterface, resultCh chan utils.Result[any]) {
-	rdsAttachments, err := e.awsRdsClient.GetRdsAttachments(ctx, awsEni)
-	if err != nil {
-		resultCh <- utils.Result[any]{Err: err}
-		return
-	}
-	resultCh <- utils.Result[any]{
-		Data: rdsAttachments,
+	attachments := make([]coreTypes.Attachment, 0)
+	for _, attachment := range results {
+		if attachment != nil {
+			attachments = append(attachments, attachment)
+		}
 	}
-}
 
-// Get the IDs of the EC2 instances attached to the Network Interface
-func getEC2Attachment(ifc ec2Types.NetworkInterface) *coreTypes.Ec2Attachment {
-	if ifc.Attachment != nil && ifc.Attachment.InstanceId != nil {
-		return &coreTypes.Ec2Attachment{InstanceId: *ifc.Attachment.InstanceId}
-	}
-	return nil
+	return attachments
 }
@@ -0,0 +1,165 @@
+package builders
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"sync"
+)
+
+// AttachmentResolver finds the AWS resource which is using a Network Interface
+type AttachmentResolver interface {
+	// Resolve returns the attachment for the network interface. If the network interface is not used by a resource
+	// known by the resolver, the returned value is nil.
+	Resolve(ctx context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error)
+}
+
+// ResolverFactory creates a new AttachmentResolver for the AWS configuration provided. Every EniDetailsBuilder
+// creates its own resolvers, so a resolver may keep a cache for the lifetime of the builder.
+type ResolverFactory func(cfg aws.Config) AttachmentResolver
+
+var (
+	registryMu        sync.RWMutex
+	resolverNames     []string
+	resolverFactories = make(map[string]ResolverFactory)
+)
+
+func init() {
+	RegisterResolver("ec2", func(cfg aws.Config) AttachmentResolver {
+		return resolverFunc(func(_ context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error) {
+			if eni.Attachment != nil && eni.Attachment.InstanceId != nil {
+				return &coreTypes.Ec2Attachment{InstanceId: *eni.Attachment.InstanceId}, nil
+			}
+			return nil, nil
+		})
+	})
+	RegisterResolver("lambda", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsLambdaClient(cfg).GetLambdaAttachment)
+	})
+	RegisterResolver("ecs", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEcsClient(cfg).GetEcsAttachment)
+	})
+	RegisterResolver("elb", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsElbClient(cfg).GetELBAttachment)
+	})
+	RegisterResolver("clb", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsClbClient(cfg).GetCLBAttachment)
+	})
+	RegisterResolver("vpce", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEc2Client(cfg).GetVpceAttachment)
+	})
+	RegisterResolver("nat", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEc2Client(cfg).GetNatGatewayAttachment)
+	})
+	RegisterResolver("tgw", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEc2Client(cfg).GetTransitGatewayAttachment)
+	})
+	RegisterResolver("efs", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEfsClient(cfg).GetEFSAttachment)
+	})
+	RegisterResolver("elasticache", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsElastiCacheClient(cfg).GetElastiCacheAttachment)
+	})
+	RegisterResolver("eks", func(cfg aws.Config) AttachmentResolver {
+		return resolverOf(clients.NewAwsEksClient(cfg).GetEKSAttachment)
+	})
+	RegisterResolver("rds", func(cfg aws.Config) AttachmentResolver {
+		client := clients.NewAwsRdsClient(cfg)
+		return resolverFunc(func(ctx context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error) {
+			attachments, err := client.GetRdsAttachments(ctx, eni)
+			if err != nil || len(attachments) == 0 {
+				return nil, err
+			}
+			return coreTypes.RdsAttachments(attachments), nil
+		})
+	})
+}
+
+// RegisterResolver makes an AttachmentResolver available to every EniDetailsBuilder created afterward. It panics if
+// the factory is nil or if a resolver with the same name is already registered.
+func RegisterResolver(name string, factory ResolverFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("builders: RegisterResolver factory is nil")
+	}
+	if _, ok := resolverFactories[name]; ok {
+		panic(fmt.Sprintf("builders: RegisterResolver called twice for resolver %s", name))
+	}
+
+	resolverNames = append(resolverNames, name)
+	resolverFactories[name] = factory
+}
+
+// UnregisterResolver removes a previously registered resolver. This can be used to replace a built-in resolver.
+func UnregisterResolver(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := resolverFactories[name]; !ok {
+		return
+	}
+
+	delete(resolverFactories, name)
+	for i, registered := range resolverNames {
+		if registered == name {
+			resolverNames = append(resolverNames[:i], resolverNames[i+1:]...)
+			break
+		}
+	}
+}
+
+// Resolvers returns the names of the registered resolvers in the order of their registration
+func Resolvers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, len(resolverNames))
+	copy(names, resolverNames)
+	return names
+}
+
+// A resolver together with the name it was registered with
+type namedResolver struct {
+	name     string
+	resolver AttachmentResolver
+}
+
+// Create a new instance of every registered resolver
+func newResolvers(cfg aws.Config) []namedResolver {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	resolvers := make([]namedResolver, 0, len(resolverNames))
+	for _, name := range resolverNames {
+		resolvers = append(resolvers, namedResolver{name: name, resolver: resolverFactories[name](cfg)})
+	}
+	return resolvers
+}
+
+// resolverFunc allows the use of an ordinary function as an AttachmentResolver
+type resolverFunc func(ctx context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error) {
+	return f(ctx, eni)
+}
+
+// Adapt a client method returning a typed attachment, which is nil if the network interface is not used by a resource
+// known by the client, to an AttachmentResolver. The nil attachment is returned as a nil interface, so that it is not
+// recorded on the network interface details
+func resolverOf[A any, P interface {
+	*A
+	coreTypes.Attachment
+}](resolve func(ctx context.Context, eni ec2Types.NetworkInterface) (P, error)) AttachmentResolver {
+	return resolverFunc(func(ctx context.Context, eni ec2Types.NetworkInterface) (coreTypes.Attachment, error) {
+		attachment, err := resolve(ctx, eni)
+		if err != nil || attachment == nil {
+			return nil, err
+		}
+		return attachment, nil
+	})
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Revoke the rules which would prevent the Security Groups from being removed one after the other: the rules of other
+// Security Groups referencing them, and the rules of the Security Groups referencing each other in a cycle. Rules of
+// Security Groups which are removed before the Security Group they reference are left in place, since they are removed
+// together with their Security Group. If a backup directory is provided, the rules are backed up before being revoked
+// and nothing is revoked if the backup fails. Returns the IDs of the Security Groups in the order of their removal, and
+// the errors of the rules which could not be revoked. The Security Groups still referenced by rules which could not be
+// revoked are skipped, together with the Security Groups they reference
+func revokeReferencingRules(ctx context.Context, t target, securityGroupIds []string, backupDir string,
+	onRuleRevoked func(rule coreTypes.RevokedRule)) ([]string, []error, error) {
+	ec2Client := clients.NewAwsEc2Client(t.cfg)
+	rules, err := ec2Client.DescribeSecurityGroupRules(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// For each Security Group to be removed, the other Security Groups to be removed referencing it
+	closure := make(map[string][]string, len(securityGroupIds))
+	for _, sgId := range securityGroupIds {
+		closure[sgId] = make([]string, 0)
+	}
+	referencingRules := make([]ec2Types.SecurityGroupRule, 0)
+	for _, rule := range rules {
+		if rule.ReferencedGroupInfo == nil || rule.ReferencedGroupInfo.GroupId == nil {
+			continue
+		}
+		referencedId := *rule.ReferencedGroupInfo.GroupId
+		referencingId := aws.ToString(rule.GroupId)
+		referencingIds, ok := closure[referencedId]
+		if !ok || referencingId == referencedId {
+			continue
+		}
+		referencingRules = append(referencingRules, rule)
+		if _, ok := closure[referencingId]; ok && !slices.Contains(referencingIds, referencingId) {
+			closure[referencedId] = append(referencingIds, referencingId)
+		}
+	}
+
+	components := getRemovalComponents(closure)
+	order := make([]string, 0, len(securityGroupIds))
+	componentOf := make(map[string]int, len(securityGroupIds))
+	for i, component := range components {
+		order = append(order, component...)
+		for _, sgId := range component {
+			componentOf[sgId] = i
+		}
+	}
+
+	// Rules to be revoked grouped by the Security Group they belong to
+	toRevoke := make(map[string][]ec2Types.SecurityGroupRule)
+	for _, rule := range referencingRules {
+		referencingId := aws.ToString(rule.GroupId)
+		referencingComponent, isRemoved := componentOf[referencingId]
+		if !isRemoved || referencingComponent == componentOf[*rule.ReferencedGroupInfo.GroupId] {
+			toRevoke[referencingId] = append(toRevoke[referencingId], rule)
+		}
+	}
+
+	sgIds := make([]string, 0, len(toRevoke))
+	for sgId := range toRevoke {
+		sgIds = append(sgIds, sgId)
+	}
+	sort.Strings(sgIds)
+
+	if backupDir != "" && len(sgIds) > 0 {
+		revokedRules := make([]coreTypes.SecurityGroupRule, 0)
+		for _, sgId := range sgIds {
+			revokedRules = append(revokedRules, toSecurityGroupRules(toRevoke[sgId])...)
+		}
+		if err := backupRevokedRules(t, revokedRules, backupDir); err != nil {
+			return nil, []error{fmt.Errorf("not removing Security Groups %s, the backup of the rules referencing "+
+				"them failed: %w", strings.Join(securityGroupIds, ", "), err)}, nil
+		}
+	}
+
+	errs := make([]error, 0)
+	blocked := make(map[string]bool)
+	for _, sgId := range sgIds {
+		ingressRuleIds := make([]string, 0)
+		egressRuleIds := make([]string, 0)
+		for _, rule := range toRevoke[sgId] {
+			if aws.ToBool(rule.IsEgress) {
+				egressRuleIds = append(egressRuleIds, aws.ToString(rule.SecurityGroupRuleId))
+			} else {
+				ingressRuleIds = append(ingressRuleIds, aws.ToString(rule.SecurityGroupRuleId))
+			}
+		}
+
+		if err := ec2Client.RevokeSecurityGroupRules(ctx, sgId, ingressRuleIds, egressRuleIds); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke the rules of Security Group %s referencing the "+
+				"Security Groups to be removed: %w", sgId, err))
+			for _, rule := range toRevoke[sgId] {
+				blocked[*rule.ReferencedGroupInfo.GroupId] = true
+			}
+			continue
+		}
+
+		if onRuleRevoked != nil {
+			for _, rule := range toRevoke[sgId] {
+				onRuleRevoked(coreTypes.RevokedRule{
+					AccountId: t.accountId,
+					Region:    t.region(),
+					Rule:      toSecurityGroupRule(rule),
+				})
+			}
+		}
+	}
+
+	removable, skipErrs := skipBlockedGroups(order, closure, blocked)
+	return removable, append(errs, skipErrs...), nil
+}
+
+// Remove from the removal order the Security Groups still referenced by rules which could not be revoked. A Security
+// Group which is not removed keeps its rules, so the Security Groups it references are not removed either. Returns
+// the Security Groups which can still be removed, in the same order, and an error for every skipped Security Group
+func skipBlockedGroups(order []string, closure map[string][]string, blocked map[string]bool) ([]string, []error) {
+	blocked = maps.Clone(blocked)
+	for isChanged := len(blocked) > 0; isChanged; {
+		isChanged = false
+		for referencedId, referencingIds := range closure {
+			if blocked[referencedId] {
+				continue
+			}
+			for _, referencingId := range referencingIds {
+				if blocked[referencingId] {
+					blocked[referencedId] = true
+					isChanged = true
+					break
+				}
+			}
+		}
+	}
+
+	removable := make([]string, 0, len(order))
+	errs := make([]error, 0)
+	for _, sgId := range order {
+		if blocked[sgId] {
+			errs = append(errs, fmt.Errorf("skipping Security Group %s: it is still referenced by rules which could "+
+				"not be revoked", sgId))
+			continue
+		}
+		removable = append(removable, sgId)
+	}
+	return removable, errs
+}
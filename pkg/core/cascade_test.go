@@ -0,0 +1,56 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSkipBlockedGroups(t *testing.T) {
+	// sg-a is referenced by sg-b, which is referenced by sg-c, while sg-d and sg-e reference each other
+	closure := map[string][]string{
+		"sg-a": {"sg-b"},
+		"sg-b": {"sg-c"},
+		"sg-c": {},
+		"sg-d": {"sg-e"},
+		"sg-e": {"sg-d"},
+	}
+	order := []string{"sg-c", "sg-b", "sg-a", "sg-d", "sg-e"}
+
+	tests := []struct {
+		name      string
+		blocked   map[string]bool
+		removable []string
+		skipped   int
+	}{
+		{
+			name:      "nothing blocked",
+			removable: []string{"sg-c", "sg-b", "sg-a", "sg-d", "sg-e"},
+		},
+		{
+			name:      "groups referenced by a blocked group are blocked",
+			blocked:   map[string]bool{"sg-c": true},
+			removable: []string{"sg-d", "sg-e"},
+			skipped:   3,
+		},
+		{
+			name:      "groups referencing a blocked group are still removed",
+			blocked:   map[string]bool{"sg-a": true},
+			removable: []string{"sg-c", "sg-b", "sg-d", "sg-e"},
+			skipped:   1,
+		},
+		{
+			name:      "blocked cycle",
+			blocked:   map[string]bool{"sg-d": true},
+			removable: []string{"sg-c", "sg-b", "sg-a"},
+			skipped:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removable, errs := skipBlockedGroups(order, closure, tt.blocked)
+			require.Equal(t, tt.removable, removable)
+			require.Len(t, errs, tt.skipped)
+		})
+	}
+}
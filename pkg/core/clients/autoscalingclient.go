@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	autoscalingTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+)
+
+type AwsAutoScalingClient struct {
+	client *autoscaling.Client
+}
+
+func NewAwsAutoScalingClient(cfg aws.Config) *AwsAutoScalingClient {
+	return &AwsAutoScalingClient{
+		client: autoscaling.NewFromConfig(cfg),
+	}
+}
+
+// DescribeLaunchConfigurations returns every launch configuration
+func (c *AwsAutoScalingClient) DescribeLaunchConfigurations(ctx context.Context) ([]autoscalingTypes.LaunchConfiguration, error) {
+	launchConfigurations := make([]autoscalingTypes.LaunchConfiguration, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.DescribeLaunchConfigurations(ctx, &autoscaling.DescribeLaunchConfigurationsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		launchConfigurations = append(launchConfigurations, response.LaunchConfigurations...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return launchConfigurations, nil
+}
+
+// DescribeAutoScalingGroups returns every Auto Scaling group, including the ones scaled to zero
+func (c *AwsAutoScalingClient) DescribeAutoScalingGroups(ctx context.Context) ([]autoscalingTypes.AutoScalingGroup, error) {
+	groups := make([]autoscalingTypes.AutoScalingGroup, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.DescribeAutoScalingGroups(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, response.AutoScalingGroups...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return groups, nil
+}
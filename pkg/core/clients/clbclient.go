@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	clbTypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"regexp"
+)
+
+// ClassicElbType is the type recorded in the ElbAttachment of a Classic Load Balancer
+const ClassicElbType = "classic"
+
+type AwsClbClient struct {
+	client *elasticloadbalancing.Client
+	cache  cmap.ConcurrentMap[string, *coreTypes.ElbAttachment]
+}
+
+func NewAwsClbClient(cfg aws.Config) *AwsClbClient {
+	return &AwsClbClient{
+		client: elasticloadbalancing.NewFromConfig(cfg),
+		cache:  cmap.New[*coreTypes.ElbAttachment](),
+	}
+}
+
+// GetCLBAttachment returns a pointer to an ElbAttachment for the network interface of a Classic Load Balancer. If there
+// is no attachment found, the returned value is a nil.
+func (c *AwsClbClient) GetCLBAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.ElbAttachment, error) {
+	// The names of Classic Load Balancers cannot contain slashes, unlike the descriptions used by the newer ones
+	regex := regexp.MustCompile("^ELB (?P<elbName>[a-zA-Z0-9-]+)$")
+	if eni.InterfaceType != ec2Types.NetworkInterfaceTypeInterface || eni.Description == nil {
+		return nil, nil
+	}
+
+	match := regex.FindStringSubmatch(*eni.Description)
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	elbName := match[regex.SubexpIndex("elbName")]
+	if cachedElb, ok := c.cache.Get(elbName); ok {
+		return cachedElb, nil
+	}
+
+	attachment := &coreTypes.ElbAttachment{
+		IsRemoved: true,
+		Name:      elbName,
+		Type:      ClassicElbType,
+	}
+
+	loadBalancers, err := c.client.DescribeLoadBalancers(ctx,
+		&elasticloadbalancing.DescribeLoadBalancersInput{LoadBalancerNames: []string{elbName}})
+	if err != nil {
+		// Handle error in case the load balancer does not exist. Do not return this error to the caller
+		var notFoundErr *clbTypes.AccessPointNotFoundException
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+	} else {
+		attachment.IsRemoved = len(loadBalancers.LoadBalancerDescriptions) == 0
+	}
+
+	c.cache.Set(elbName, attachment)
+	return attachment, nil
+}
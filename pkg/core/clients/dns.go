@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// The time after which the lookup of an endpoint is abandoned
+	dnsLookupTimeout = 5 * time.Second
+	// The number of endpoints resolved at the same time
+	dnsLookupConcurrency = 16
+)
+
+// Resolve the IP addresses of an endpoint of a managed service. Endpoints which cannot be resolved, for example because
+// they are private or the lookup times out, have no addresses
+func lookupAddresses(ctx context.Context, address string) []string {
+	ctx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupHost(ctx, address)
+	if err != nil {
+		return nil
+	}
+	return addresses
+}
+
+// Resolve the IP addresses of several endpoints concurrently. The addresses are returned by endpoint, endpoints which
+// cannot be resolved have no addresses
+func lookupAllAddresses(ctx context.Context, endpoints []string) map[string][]string {
+	addresses := make(map[string][]string, len(endpoints))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, dnsLookupConcurrency)
+	for _, endpoint := range endpoints {
+		endpoint := endpoint // capture value
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			resolved := lookupAddresses(ctx, endpoint)
+			mu.Lock()
+			addresses[endpoint] = resolved
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return addresses
+}
@@ -2,64 +2,91 @@ package clients
 
 import (
 	"context"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"regexp"
+	"strings"
 )
 
 const MaxResults = 1000
 
+// The maximum number of values accepted by a filter
+const maxFilterValues = 200
+
 type AwsEc2Client struct {
 	client    *ec2.Client
 	vpceCache cmap.ConcurrentMap[string, *coreTypes.VpceAttachment]
+	natCache  cmap.ConcurrentMap[string, *coreTypes.NatGatewayAttachment]
+	tgwCache  cmap.ConcurrentMap[string, *coreTypes.TransitGatewayAttachment]
 }
 
 func NewAwsEc2Client(cfg aws.Config) *AwsEc2Client {
 	return &AwsEc2Client{
 		client:    ec2.NewFromConfig(cfg),
 		vpceCache: cmap.New[*coreTypes.VpceAttachment](),
+		natCache:  cmap.New[*coreTypes.NatGatewayAttachment](),
+		tgwCache:  cmap.New[*coreTypes.TransitGatewayAttachment](),
 	}
 }
 
 // DescribeSecurityGroups fetches all the Security Groups based on the list of the IDs provided. If the list  is empty,
-// all the existing interfaces will be returned.
+// all the existing interfaces will be returned. IDs which do not exist are ignored.
 // This function expects a channel to which the response will be provided asynchronously
 func (c *AwsEc2Client) DescribeSecurityGroups(ctx context.Context, securityGroupIds []string,
 	resultCh chan utils.Result[[]ec2Types.SecurityGroup]) {
 	go func() {
 		defer close(resultCh)
 
-		var nextToken *string = nil
 		securityGroups := make([]ec2Types.SecurityGroup, 0)
-		for {
-			sgResponse, err := c.client.DescribeSecurityGroups(ctx,
-				&ec2.DescribeSecurityGroupsInput{
-					NextToken: nextToken,
-					GroupIds:  securityGroupIds,
-				})
-			if err != nil {
-				resultCh <- utils.Result[[]ec2Types.SecurityGroup]{
-					Err: err,
+		for _, filters := range getIdFilters("group-id", securityGroupIds) {
+			var nextToken *string = nil
+			for {
+				sgResponse, err := c.client.DescribeSecurityGroups(ctx,
+					&ec2.DescribeSecurityGroupsInput{
+						NextToken: nextToken,
+						Filters:   filters,
+					})
+				if err != nil {
+					resultCh <- utils.Result[[]ec2Types.SecurityGroup]{
+						Err: err,
+					}
+					return
 				}
-				return
-			}
-			nextToken = sgResponse.NextToken
-			securityGroups = append(securityGroups, sgResponse.SecurityGroups...)
+				nextToken = sgResponse.NextToken
+				securityGroups = append(securityGroups, sgResponse.SecurityGroups...)
 
-			if nextToken == nil {
-				resultCh <- utils.Result[[]ec2Types.SecurityGroup]{
-					Data: securityGroups,
+				if nextToken == nil {
+					break
 				}
-				return
 			}
 		}
+		resultCh <- utils.Result[[]ec2Types.SecurityGroup]{
+			Data: securityGroups,
+		}
 	}()
 }
 
+// Get the filters selecting the resources by their IDs, split so that every filter accepts at most maxFilterValues
+// values. If no IDs are provided, a single empty list of filters is returned, which selects every resource
+func getIdFilters(name string, ids []string) [][]ec2Types.Filter {
+	if len(ids) == 0 {
+		return [][]ec2Types.Filter{nil}
+	}
+
+	filters := make([][]ec2Types.Filter, 0, (len(ids)+maxFilterValues-1)/maxFilterValues)
+	for start := 0; start < len(ids); start += maxFilterValues {
+		end := min(start+maxFilterValues, len(ids))
+		filters = append(filters, []ec2Types.Filter{{Name: aws.String(name), Values: ids[start:end]}})
+	}
+	return filters
+}
+
 // DescribeSecurityGroupRules returns all the Security Group Rules. (TODO: try to optimise this to grab a sublist only)
 func (c *AwsEc2Client) DescribeSecurityGroupRules(ctx context.Context) ([]ec2Types.SecurityGroupRule, error) {
 	var nextToken *string = nil
@@ -81,30 +108,139 @@ func (c *AwsEc2Client) DescribeSecurityGroupRules(ctx context.Context) ([]ec2Typ
 	return securityGroupRules, nil
 }
 
+// DescribeSecurityGroupRulesByGroupIds returns the inbound and outbound rules of the Security Groups from the input slice
+func (c *AwsEc2Client) DescribeSecurityGroupRulesByGroupIds(ctx context.Context, securityGroupIds []string) ([]ec2Types.SecurityGroupRule, error) {
+	securityGroupRules := make([]ec2Types.SecurityGroupRule, 0)
+	if len(securityGroupIds) == 0 {
+		return securityGroupRules, nil
+	}
+
+	for _, filters := range getIdFilters("group-id", securityGroupIds) {
+		var nextToken *string = nil
+		for {
+			sgResponse, err := c.client.DescribeSecurityGroupRules(ctx,
+				&ec2.DescribeSecurityGroupRulesInput{
+					NextToken:  nextToken,
+					MaxResults: aws.Int32(int32(MaxResults)),
+					Filters:    filters,
+				})
+			if err != nil {
+				return nil, err
+			}
+			nextToken = sgResponse.NextToken
+			securityGroupRules = append(securityGroupRules, sgResponse.SecurityGroupRules...)
+
+			if nextToken == nil {
+				break
+			}
+		}
+	}
+
+	return securityGroupRules, nil
+}
+
+// CreateSecurityGroup creates a new Security Group with the tags provided and returns its ID
+func (c *AwsEc2Client) CreateSecurityGroup(ctx context.Context, name string, description string, vpcId string,
+	tags map[string]string) (string, error) {
+	input := &ec2.CreateSecurityGroupInput{
+		GroupName:   aws.String(name),
+		Description: aws.String(description),
+		VpcId:       aws.String(vpcId),
+	}
+
+	if len(tags) > 0 {
+		tagSpecification := ec2Types.TagSpecification{ResourceType: ec2Types.ResourceTypeSecurityGroup}
+		for key, value := range tags {
+			tagSpecification.Tags = append(tagSpecification.Tags, ec2Types.Tag{Key: aws.String(key), Value: aws.String(value)})
+		}
+		input.TagSpecifications = []ec2Types.TagSpecification{tagSpecification}
+	}
+
+	response, err := c.client.CreateSecurityGroup(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	return *response.GroupId, nil
+}
+
+// AuthorizeSecurityGroupRules adds the inbound and outbound IP permissions provided to a Security Group
+func (c *AwsEc2Client) AuthorizeSecurityGroupRules(ctx context.Context, securityGroupId string,
+	ingress []ec2Types.IpPermission, egress []ec2Types.IpPermission) error {
+	if len(ingress) > 0 {
+		_, err := c.client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(securityGroupId),
+			IpPermissions: ingress,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(egress) > 0 {
+		_, err := c.client.AuthorizeSecurityGroupEgress(ctx, &ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(securityGroupId),
+			IpPermissions: egress,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeSecurityGroupRules removes the inbound and outbound rules identified by their IDs from a Security Group
+func (c *AwsEc2Client) RevokeSecurityGroupRules(ctx context.Context, securityGroupId string, ingressRuleIds []string,
+	egressRuleIds []string) error {
+	if len(ingressRuleIds) > 0 {
+		_, err := c.client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:              aws.String(securityGroupId),
+			SecurityGroupRuleIds: ingressRuleIds,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(egressRuleIds) > 0 {
+		_, err := c.client.RevokeSecurityGroupEgress(ctx, &ec2.RevokeSecurityGroupEgressInput{
+			GroupId:              aws.String(securityGroupId),
+			SecurityGroupRuleIds: egressRuleIds,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DescribeNetworkInterfaces fetches all the Network Interfaces based on the list of the ENI IDs provided. If the list
-// is empty, all the existing interfaces will be returned.
+// is empty, all the existing interfaces will be returned. IDs which do not exist are ignored.
 // This function expects a channel to which the response will be provided asynchronously
 func (c *AwsEc2Client) DescribeNetworkInterfaces(ctx context.Context, eniIds []string, resultCh chan utils.Result[[]ec2Types.NetworkInterface]) {
 	go func() {
 		defer close(resultCh)
-		var nextToken *string = nil
-		for {
-			ifcResponse, err := c.client.DescribeNetworkInterfaces(ctx,
-				&ec2.DescribeNetworkInterfacesInput{NextToken: nextToken, NetworkInterfaceIds: eniIds})
-			if err != nil {
-				resultCh <- utils.Result[[]ec2Types.NetworkInterface]{
-					Err: err,
+		for _, filters := range getIdFilters("network-interface-id", eniIds) {
+			var nextToken *string = nil
+			for {
+				ifcResponse, err := c.client.DescribeNetworkInterfaces(ctx,
+					&ec2.DescribeNetworkInterfacesInput{NextToken: nextToken, Filters: filters})
+				if err != nil {
+					resultCh <- utils.Result[[]ec2Types.NetworkInterface]{
+						Err: err,
+					}
+					return
 				}
-				return
-			}
 
-			resultCh <- utils.Result[[]ec2Types.NetworkInterface]{
-				Data: ifcResponse.NetworkInterfaces,
-			}
-			nextToken = ifcResponse.NextToken
+				resultCh <- utils.Result[[]ec2Types.NetworkInterface]{
+					Data: ifcResponse.NetworkInterfaces,
+				}
+				nextToken = ifcResponse.NextToken
 
-			if nextToken == nil {
-				return
+				if nextToken == nil {
+					break
+				}
 			}
 		}
 	}()
@@ -137,11 +273,13 @@ func (c *AwsEc2Client) DescribeNetworkInterfacesBySecurityGroups(ctx context.Con
 	return networkInterfaces, nil
 }
 
-// GetVpceAttachment returns a pointer to a VPCEAttachment for the network interface. If there is no attachment found,
-// the returned value is a nil.
+// GetVpceAttachment returns a pointer to a VPCEAttachment for the network interface of an Interface or a Gateway Load
+// Balancer VPC Endpoint. If there is no attachment found, the returned value is a nil.
 func (c *AwsEc2Client) GetVpceAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.VpceAttachment, error) {
 	regex := regexp.MustCompile("VPC Endpoint Interface (?P<vpceId>vpce-([a-z]|[0-9])+)")
-	if eni.InterfaceType == ec2Types.NetworkInterfaceTypeVpcEndpoint && eni.Description != nil {
+	isVpceInterface := eni.InterfaceType == ec2Types.NetworkInterfaceTypeVpcEndpoint ||
+		eni.InterfaceType == ec2Types.NetworkInterfaceTypeGatewayLoadBalancerEndpoint
+	if isVpceInterface && eni.Description != nil {
 		match := regex.FindStringSubmatch(*eni.Description)
 
 		if len(match) > 0 {
@@ -178,6 +316,274 @@ func (c *AwsEc2Client) GetVpceAttachment(ctx context.Context, eni ec2Types.Netwo
 	return nil, nil
 }
 
+// GetNatGatewayAttachment returns a pointer to a NatGatewayAttachment for the network interface of a NAT Gateway. The
+// NAT Gateway is considered removed if it does not exist anymore, or if it is deleted, being deleted or failed. If there
+// is no attachment found, the returned value is a nil.
+func (c *AwsEc2Client) GetNatGatewayAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.NatGatewayAttachment, error) {
+	regex := regexp.MustCompile("Interface for NAT Gateway (?P<natId>nat-([a-z]|[0-9])+)")
+	// The SDK enum does not match the value returned by the API, so we accept both of them
+	isNatInterface := eni.InterfaceType == ec2Types.NetworkInterfaceTypeNatGateway || eni.InterfaceType == "nat_gateway"
+	if !isNatInterface || eni.Description == nil {
+		return nil, nil
+	}
+
+	match := regex.FindStringSubmatch(*eni.Description)
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	natId := match[regex.SubexpIndex("natId")]
+	if cachedNat, ok := c.natCache.Get(natId); ok {
+		return cachedNat, nil
+	}
+
+	// Use a filter instead of the NAT Gateway ID, so that we get an empty response instead of an error if the NAT
+	// Gateway does not exist
+	natResponse, err := c.client.DescribeNatGateways(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: []ec2Types.Filter{{
+			Name:   aws.String("nat-gateway-id"),
+			Values: []string{natId},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &coreTypes.NatGatewayAttachment{
+		IsRemoved: true,
+		Id:        natId,
+	}
+	for _, nat := range natResponse.NatGateways {
+		attachment.State = string(nat.State)
+		attachment.IsRemoved = nat.State == ec2Types.NatGatewayStateDeleted ||
+			nat.State == ec2Types.NatGatewayStateDeleting || nat.State == ec2Types.NatGatewayStateFailed
+	}
+
+	c.natCache.Set(natId, attachment)
+	return attachment, nil
+}
+
+// GetTransitGatewayAttachment returns a pointer to a TransitGatewayAttachment for the network interface of a Transit
+// Gateway VPC attachment. The VPC attachment is considered removed if it does not exist anymore, or if it is deleted,
+// being deleted, failed or rejected. If there is no attachment found, the returned value is a nil.
+func (c *AwsEc2Client) GetTransitGatewayAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.TransitGatewayAttachment, error) {
+	regex := regexp.MustCompile("Network Interface for Transit Gateway Attachment (?P<attachmentId>tgw-attach-([a-z]|[0-9])+)")
+	if eni.InterfaceType != ec2Types.NetworkInterfaceTypeTransitGateway || eni.Description == nil {
+		return nil, nil
+	}
+
+	match := regex.FindStringSubmatch(*eni.Description)
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	attachmentId := match[regex.SubexpIndex("attachmentId")]
+	if cachedTgw, ok := c.tgwCache.Get(attachmentId); ok {
+		return cachedTgw, nil
+	}
+
+	tgwResponse, err := c.client.DescribeTransitGatewayVpcAttachments(ctx, &ec2.DescribeTransitGatewayVpcAttachmentsInput{
+		Filters: []ec2Types.Filter{{
+			Name:   aws.String("transit-gateway-attachment-id"),
+			Values: []string{attachmentId},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &coreTypes.TransitGatewayAttachment{
+		IsRemoved:    true,
+		AttachmentId: attachmentId,
+	}
+	for _, vpcAttachment := range tgwResponse.TransitGatewayVpcAttachments {
+		attachment.TransitGatewayId = vpcAttachment.TransitGatewayId
+		attachment.State = string(vpcAttachment.State)
+		switch vpcAttachment.State {
+		case ec2Types.TransitGatewayAttachmentStateDeleted, ec2Types.TransitGatewayAttachmentStateDeleting,
+			ec2Types.TransitGatewayAttachmentStateFailed, ec2Types.TransitGatewayAttachmentStateFailing,
+			ec2Types.TransitGatewayAttachmentStateRejected, ec2Types.TransitGatewayAttachmentStateRejecting:
+			attachment.IsRemoved = true
+		default:
+			attachment.IsRemoved = false
+		}
+	}
+
+	c.tgwCache.Set(attachmentId, attachment)
+	return attachment, nil
+}
+
+// DescribeLaunchTemplateVersions returns the versions of a launch template, identified either by its ID or by its name.
+// If neither of them is provided, the versions are returned for every launch template, which is allowed only for the
+// $Latest and $Default versions. A launch template or a version which does not exist is ignored.
+func (c *AwsEc2Client) DescribeLaunchTemplateVersions(ctx context.Context, launchTemplateId *string,
+	launchTemplateName *string, versions []string) ([]ec2Types.LaunchTemplateVersion, error) {
+	launchTemplateVersions := make([]ec2Types.LaunchTemplateVersion, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.DescribeLaunchTemplateVersions(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId:   launchTemplateId,
+			LaunchTemplateName: launchTemplateName,
+			Versions:           versions,
+			NextToken:          nextToken,
+		})
+		if err != nil {
+			// Handle error in case the launch template or the version does not exist. Do not return this error to
+			// the caller
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.ErrorCode(), "InvalidLaunchTemplate") {
+				return launchTemplateVersions, nil
+			}
+			return nil, err
+		}
+
+		launchTemplateVersions = append(launchTemplateVersions, response.LaunchTemplateVersions...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return launchTemplateVersions, nil
+}
+
+// GetDefaultVpcId returns the ID of the default VPC of the region. If the region has no default VPC, the returned value
+// is an empty string.
+func (c *AwsEc2Client) GetDefaultVpcId(ctx context.Context) (string, error) {
+	response, err := c.client.DescribeVpcs(ctx, &ec2.DescribeVpcsInput{
+		Filters: []ec2Types.Filter{{
+			Name:   aws.String("is-default"),
+			Values: []string{"true"},
+		}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, vpc := range response.Vpcs {
+		return aws.ToString(vpc.VpcId), nil
+	}
+	return "", nil
+}
+
+// GetSubnetVpcIds returns the ID of the VPC of every subnet from the input slice, keyed by the ID of the subnet.
+// Subnets which do not exist are ignored.
+func (c *AwsEc2Client) GetSubnetVpcIds(ctx context.Context, subnetIds []string) (map[string]string, error) {
+	vpcIds := make(map[string]string)
+	if len(subnetIds) == 0 {
+		return vpcIds, nil
+	}
+
+	// Use a filter instead of the IDs, so that we get an empty response instead of an error if a subnet does not exist
+	for _, filters := range getIdFilters("subnet-id", subnetIds) {
+		var nextToken *string
+		for {
+			response, err := c.client.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
+				Filters:   filters,
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, subnet := range response.Subnets {
+				vpcIds[aws.ToString(subnet.SubnetId)] = aws.ToString(subnet.VpcId)
+			}
+
+			if response.NextToken == nil {
+				break
+			}
+			nextToken = response.NextToken
+		}
+	}
+	return vpcIds, nil
+}
+
+// GetManagedPrefixListEntries returns the CIDR blocks of a managed prefix list. A prefix list which does not exist or
+// which is not shared with the account is ignored.
+func (c *AwsEc2Client) GetManagedPrefixListEntries(ctx context.Context, prefixListId string) ([]string, error) {
+	cidrs := make([]string, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.GetManagedPrefixListEntries(ctx, &ec2.GetManagedPrefixListEntriesInput{
+			PrefixListId: aws.String(prefixListId),
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			// Handle error in case the prefix list does not exist. Do not return this error to the caller
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.ErrorCode(), "InvalidPrefixListID") {
+				return cidrs, nil
+			}
+			return nil, err
+		}
+
+		for _, entry := range response.Entries {
+			if entry.Cidr != nil {
+				cidrs = append(cidrs, *entry.Cidr)
+			}
+		}
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return cidrs, nil
+}
+
+// GetExistingSecurityGroupIds returns the IDs from the input slice which belong to existing Security Groups
+func (c *AwsEc2Client) GetExistingSecurityGroupIds(ctx context.Context, securityGroupIds []string) ([]string, error) {
+	existingIds := make([]string, 0)
+	if len(securityGroupIds) == 0 {
+		return existingIds, nil
+	}
+
+	// Use a filter instead of the IDs, so that we get an empty response instead of an error if a Security Group does
+	// not exist
+	for _, filters := range getIdFilters("group-id", securityGroupIds) {
+		var nextToken *string
+		for {
+			sgResponse, err := c.client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{
+				Filters:   filters,
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, sg := range sgResponse.SecurityGroups {
+				existingIds = append(existingIds, aws.ToString(sg.GroupId))
+			}
+
+			if sgResponse.NextToken != nil {
+				nextToken = sgResponse.NextToken
+			} else {
+				break
+			}
+		}
+	}
+	return existingIds, nil
+}
+
+// DescribeRegions returns the names of the regions enabled for the account
+func (c *AwsEc2Client) DescribeRegions(ctx context.Context) ([]string, error) {
+	regionsResponse, err := c.client.DescribeRegions(ctx, &ec2.DescribeRegionsInput{AllRegions: aws.Bool(false)})
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]string, 0, len(regionsResponse.Regions))
+	for _, region := range regionsResponse.Regions {
+		if region.RegionName != nil {
+			regions = append(regions, *region.RegionName)
+		}
+	}
+	return regions, nil
+}
+
 // TryRemoveAllSecurityGroups attempts to remove all the Security Groups from the list of IDs provided as input. If
 // there is an error encountered for a removal, the function will not stop early.
 func (c *AwsEc2Client) TryRemoveAllSecurityGroups(ctx context.Context, securityGroupIds []string,
@@ -210,6 +616,27 @@ func (c *AwsEc2Client) TryRemoveAllSecurityGroups(ctx context.Context, securityG
 	}()
 }
 
+// RemoveSecurityGroupsInOrder removes the Security Groups one after the other, in the order of the list of IDs provided
+// as input. If there is an error encountered for a removal, the function will not stop early.
+func (c *AwsEc2Client) RemoveSecurityGroupsInOrder(ctx context.Context, securityGroupIds []string,
+	resultCh chan utils.Result[string]) {
+	go func() {
+		defer close(resultCh)
+		for _, sgId := range securityGroupIds {
+			_, err := c.client.DeleteSecurityGroup(ctx, &ec2.DeleteSecurityGroupInput{GroupId: aws.String(sgId)})
+			if err != nil {
+				resultCh <- utils.Result[string]{
+					Err: err,
+				}
+			} else {
+				resultCh <- utils.Result[string]{
+					Data: sgId,
+				}
+			}
+		}
+	}()
+}
+
 // TryRemoveAllENIs attempts to remove all the Elastic Network interfaces from the list of IDs provided as input. If
 // there is an error encountered for a removal, the function will not stop early.
 func (c *AwsEc2Client) TryRemoveAllENIs(ctx context.Context, eniIds []string,
@@ -0,0 +1,52 @@
+package clients
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGetIdFilters(t *testing.T) {
+	newIds := func(count int) []string {
+		ids := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			ids = append(ids, fmt.Sprintf("sg-%d", i))
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		ids    []string
+		chunks []int
+	}{
+		{name: "no IDs", ids: nil, chunks: []int{0}},
+		{name: "single chunk", ids: newIds(3), chunks: []int{3}},
+		{name: "exactly the maximum", ids: newIds(maxFilterValues), chunks: []int{maxFilterValues}},
+		{name: "multiple chunks", ids: newIds(2*maxFilterValues + 1), chunks: []int{maxFilterValues, maxFilterValues, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters := getIdFilters("group-id", tt.ids)
+			require.Len(t, filters, len(tt.chunks))
+
+			values := make([]string, 0, len(tt.ids))
+			for i, chunk := range filters {
+				if tt.chunks[i] == 0 {
+					require.Empty(t, chunk)
+					continue
+				}
+
+				require.Len(t, chunk, 1)
+				require.Equal(t, "group-id", aws.ToString(chunk[0].Name))
+				require.Len(t, chunk[0].Values, tt.chunks[i])
+				values = append(values, chunk[0].Values...)
+			}
+			if len(tt.ids) > 0 {
+				require.Equal(t, tt.ids, values)
+			}
+		})
+	}
+}
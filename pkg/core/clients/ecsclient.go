@@ -5,6 +5,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecsTypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"regexp"
@@ -53,21 +54,9 @@ func (c *AwsEcsClient) GetEcsAttachment(ctx context.Context, eni ec2Types.Networ
 func (c *AwsEcsClient) buildCache(ctx context.Context) error {
 	var nexToken *string
 
-	clusterArns := make([]string, 0)
-
-	for {
-		clusters, err := c.client.ListClusters(ctx, &ecs.ListClustersInput{NextToken: nexToken})
-		if err != nil {
-			return err
-		}
-
-		for _, arn := range clusters.ClusterArns {
-			clusterArns = append(clusterArns, arn)
-		}
-
-		if nexToken == nil {
-			break
-		}
+	clusterArns, err := c.listClusterArns(ctx)
+	if err != nil {
+		return err
 	}
 
 	for _, clusterArn := range clusterArns {
@@ -108,6 +97,92 @@ func (c *AwsEcsClient) buildCache(ctx context.Context) error {
 	return nil
 }
 
+// ListServices returns every service of every cluster, including the ones scaled to zero
+func (c *AwsEcsClient) ListServices(ctx context.Context) ([]ecsTypes.Service, error) {
+	clusterArns, err := c.listClusterArns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]ecsTypes.Service, 0)
+	for _, clusterArn := range clusterArns {
+		clusterArn := clusterArn // capture value
+		var nextToken *string
+		for {
+			servicesResponse, err := c.client.ListServices(ctx, &ecs.ListServicesInput{
+				Cluster:   &clusterArn,
+				NextToken: nextToken,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			// Services can be described in batches of 10
+			for start := 0; start < len(servicesResponse.ServiceArns); start += 10 {
+				end := min(start+10, len(servicesResponse.ServiceArns))
+				describeServicesResponse, err := c.client.DescribeServices(ctx, &ecs.DescribeServicesInput{
+					Cluster:  &clusterArn,
+					Services: servicesResponse.ServiceArns[start:end],
+				})
+				if err != nil {
+					return nil, err
+				}
+				services = append(services, describeServicesResponse.Services...)
+			}
+
+			if servicesResponse.NextToken != nil {
+				nextToken = servicesResponse.NextToken
+			} else {
+				break
+			}
+		}
+	}
+	return services, nil
+}
+
+// DescribeCapacityProviders returns every capacity provider
+func (c *AwsEcsClient) DescribeCapacityProviders(ctx context.Context) ([]ecsTypes.CapacityProvider, error) {
+	capacityProviders := make([]ecsTypes.CapacityProvider, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.DescribeCapacityProviders(ctx, &ecs.DescribeCapacityProvidersInput{
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		capacityProviders = append(capacityProviders, response.CapacityProviders...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return capacityProviders, nil
+}
+
+func (c *AwsEcsClient) listClusterArns(ctx context.Context) ([]string, error) {
+	clusterArns := make([]string, 0)
+	var nextToken *string
+	for {
+		clusters, err := c.client.ListClusters(ctx, &ecs.ListClustersInput{NextToken: nextToken})
+		if err != nil {
+			return nil, err
+		}
+
+		clusterArns = append(clusterArns, clusters.ClusterArns...)
+
+		if clusters.NextToken != nil {
+			nextToken = clusters.NextToken
+		} else {
+			break
+		}
+	}
+	return clusterArns, nil
+}
+
 func (c *AwsEcsClient) getAttachmentFromCache(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.EcsAttachment, error) {
 	regex := regexp.MustCompile(".+attachment/(?P<attachmentId>.+)")
 	match := regex.FindStringSubmatch(*eni.Description)
@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/efs"
+	efsTypes "github.com/aws/aws-sdk-go-v2/service/efs/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"regexp"
+)
+
+type AwsEfsClient struct {
+	client *efs.Client
+	cache  cmap.ConcurrentMap[string, *efsTypes.FileSystemDescription]
+}
+
+func NewAwsEfsClient(cfg aws.Config) *AwsEfsClient {
+	return &AwsEfsClient{
+		client: efs.NewFromConfig(cfg),
+		cache:  cmap.New[*efsTypes.FileSystemDescription](),
+	}
+}
+
+// GetEFSAttachment returns a pointer to an EfsAttachment for the network interface of an EFS mount target. If there is
+// no attachment found, the returned value is a nil.
+func (c *AwsEfsClient) GetEFSAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.EfsAttachment, error) {
+	regex := regexp.MustCompile("EFS mount target for (?P<fsId>fs-([a-z]|[0-9])+) \\((?P<fsmtId>fsmt-([a-z]|[0-9])+)\\)")
+	if eni.InterfaceType != ec2Types.NetworkInterfaceTypeInterface || eni.Description == nil {
+		return nil, nil
+	}
+
+	match := regex.FindStringSubmatch(*eni.Description)
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	fsId := match[regex.SubexpIndex("fsId")]
+	fileSystem, err := c.getFileSystem(ctx, fsId)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &coreTypes.EfsAttachment{
+		IsRemoved:     fileSystem == nil,
+		FileSystemId:  fsId,
+		MountTargetId: match[regex.SubexpIndex("fsmtId")],
+	}
+	if fileSystem != nil {
+		attachment.Name = fileSystem.Name
+	}
+
+	return attachment, nil
+}
+
+// Get the description of a file system. If the file system does not exist, the returned value will be nil
+func (c *AwsEfsClient) getFileSystem(ctx context.Context, fsId string) (*efsTypes.FileSystemDescription, error) {
+	if cachedFs, ok := c.cache.Get(fsId); ok {
+		return cachedFs, nil
+	}
+
+	var fileSystem *efsTypes.FileSystemDescription
+	fsResponse, err := c.client.DescribeFileSystems(ctx, &efs.DescribeFileSystemsInput{FileSystemId: &fsId})
+	if err != nil {
+		// Handle error in case the file system does not exist. Do not return this error to the caller
+		var notFoundErr *efsTypes.FileSystemNotFound
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+	} else if len(fsResponse.FileSystems) > 0 {
+		fileSystem = &fsResponse.FileSystems[0]
+	}
+
+	c.cache.Set(fsId, fileSystem)
+	return fileSystem, nil
+}
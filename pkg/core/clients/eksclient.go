@@ -0,0 +1,218 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	eksTypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
+	cmap "github.com/orcaman/concurrent-map/v2"
+	"regexp"
+	"strings"
+)
+
+const (
+	eksControlPlaneDescriptionPrefix = "Amazon EKS "
+
+	// Tags set by the VPC CNI on the interfaces created for the nodes
+	cniClusterNameTag = "cluster.k8s.amazonaws.com/name"
+	cniInstanceIdTag  = "node.k8s.amazonaws.com/instance_id"
+
+	// Tags set by the VPC resource controller on the branch interfaces created for security groups for pods
+	branchClusterNameTag = "vpcresources.k8s.aws/cluster-name"
+	branchTrunkIdTag     = "vpcresources.k8s.aws/trunk-eni-id"
+)
+
+type AwsEksClient struct {
+	client        *eks.Client
+	ec2Client     *ec2.Client
+	clusterCache  cmap.ConcurrentMap[string, bool]
+	instanceCache cmap.ConcurrentMap[string, bool]
+	trunkCache    cmap.ConcurrentMap[string, bool]
+}
+
+func NewAwsEksClient(cfg aws.Config) *AwsEksClient {
+	return &AwsEksClient{
+		client:        eks.NewFromConfig(cfg),
+		ec2Client:     ec2.NewFromConfig(cfg),
+		clusterCache:  cmap.New[bool](),
+		instanceCache: cmap.New[bool](),
+		trunkCache:    cmap.New[bool](),
+	}
+}
+
+// GetEKSAttachment returns a pointer to an EksAttachment for the network interfaces of an EKS control plane, for the
+// interfaces created by the VPC CNI for a node and for the trunk and branch interfaces used by security groups for
+// pods. If there is no attachment found, the returned value is a nil.
+func (c *AwsEksClient) GetEKSAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.EksAttachment, error) {
+	description := aws.ToString(eni.Description)
+	tags := utils.TagsToMap(eni.TagSet)
+
+	switch {
+	case eni.InterfaceType == ec2Types.NetworkInterfaceTypeBranch:
+		return c.getBranchAttachment(ctx, tags)
+	case eni.InterfaceType == ec2Types.NetworkInterfaceTypeTrunk:
+		return c.getNodeAttachment(ctx, eni, coreTypes.EksTrunk, tags)
+	case eni.InterfaceType != ec2Types.NetworkInterfaceTypeInterface:
+		return nil, nil
+	case strings.HasPrefix(description, eksControlPlaneDescriptionPrefix):
+		return c.getControlPlaneAttachment(ctx, strings.TrimPrefix(description, eksControlPlaneDescriptionPrefix))
+	case strings.HasPrefix(description, "aws-K8S-") || tags[cniInstanceIdTag] != "":
+		return c.getNodeAttachment(ctx, eni, coreTypes.EksNode, tags)
+	}
+	return nil, nil
+}
+
+func (c *AwsEksClient) getControlPlaneAttachment(ctx context.Context, clusterName string) (*coreTypes.EksAttachment, error) {
+	exists, err := c.clusterExists(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return &coreTypes.EksAttachment{
+		IsRemoved:   !exists,
+		Kind:        coreTypes.EksControlPlane,
+		ClusterName: &clusterName,
+	}, nil
+}
+
+// Attribute an interface to the node instance using it. The instance is taken from the attachment of the interface,
+// from the tag set by the VPC CNI or from the description of the interface, in this order. An interface which is not
+// attached to a running instance is considered leaked.
+func (c *AwsEksClient) getNodeAttachment(ctx context.Context, eni ec2Types.NetworkInterface, kind string,
+	tags map[string]string) (*coreTypes.EksAttachment, error) {
+	attachment := &coreTypes.EksAttachment{
+		Kind:        kind,
+		ClusterName: getTagValue(tags, cniClusterNameTag),
+	}
+
+	if eni.Attachment != nil && eni.Attachment.InstanceId != nil {
+		attachment.InstanceId = eni.Attachment.InstanceId
+		return attachment, nil
+	}
+
+	attachment.InstanceId = getTagValue(tags, cniInstanceIdTag)
+	if attachment.InstanceId == nil {
+		regex := regexp.MustCompile("^aws-K8S-(?P<instanceId>i-([a-z]|[0-9])+)$")
+		match := regex.FindStringSubmatch(aws.ToString(eni.Description))
+		if len(match) > 0 {
+			attachment.InstanceId = aws.String(match[regex.SubexpIndex("instanceId")])
+		}
+	}
+
+	if attachment.InstanceId == nil {
+		attachment.IsRemoved = true
+		return attachment, nil
+	}
+
+	isRunning, err := c.instanceIsRunning(ctx, *attachment.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	attachment.IsRemoved = !isRunning
+	return attachment, nil
+}
+
+// Attribute a branch interface to its trunk interface. A branch interface is leaked if its trunk interface does not
+// exist anymore.
+func (c *AwsEksClient) getBranchAttachment(ctx context.Context, tags map[string]string) (*coreTypes.EksAttachment, error) {
+	attachment := &coreTypes.EksAttachment{
+		Kind:             coreTypes.EksBranch,
+		ClusterName:      getTagValue(tags, branchClusterNameTag),
+		TrunkInterfaceId: getTagValue(tags, branchTrunkIdTag),
+	}
+
+	if attachment.TrunkInterfaceId == nil {
+		return attachment, nil
+	}
+
+	exists, err := c.trunkExists(ctx, *attachment.TrunkInterfaceId)
+	if err != nil {
+		return nil, err
+	}
+	attachment.IsRemoved = !exists
+	return attachment, nil
+}
+
+func (c *AwsEksClient) clusterExists(ctx context.Context, clusterName string) (bool, error) {
+	if exists, ok := c.clusterCache.Get(clusterName); ok {
+		return exists, nil
+	}
+
+	exists := true
+	_, err := c.client.DescribeCluster(ctx, &eks.DescribeClusterInput{Name: &clusterName})
+	if err != nil {
+		// Handle error in case the cluster does not exist. Do not return this error to the caller
+		var notFoundErr *eksTypes.ResourceNotFoundException
+		if !errors.As(err, &notFoundErr) {
+			return false, err
+		}
+		exists = false
+	}
+
+	c.clusterCache.Set(clusterName, exists)
+	return exists, nil
+}
+
+// Check if an instance exists and it is not being terminated
+func (c *AwsEksClient) instanceIsRunning(ctx context.Context, instanceId string) (bool, error) {
+	if isRunning, ok := c.instanceCache.Get(instanceId); ok {
+		return isRunning, nil
+	}
+
+	// Use a filter instead of the instance ID, so that we get an empty response instead of an error if the instance
+	// does not exist
+	instancesResponse, err := c.ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		Filters: []ec2Types.Filter{{
+			Name:   aws.String("instance-id"),
+			Values: []string{instanceId},
+		}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	isRunning := false
+	for _, reservation := range instancesResponse.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.State != nil && instance.State.Name != ec2Types.InstanceStateNameShuttingDown &&
+				instance.State.Name != ec2Types.InstanceStateNameTerminated {
+				isRunning = true
+			}
+		}
+	}
+
+	c.instanceCache.Set(instanceId, isRunning)
+	return isRunning, nil
+}
+
+func (c *AwsEksClient) trunkExists(ctx context.Context, trunkId string) (bool, error) {
+	if exists, ok := c.trunkCache.Get(trunkId); ok {
+		return exists, nil
+	}
+
+	interfacesResponse, err := c.ec2Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []ec2Types.Filter{{
+			Name:   aws.String("network-interface-id"),
+			Values: []string{trunkId},
+		}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	exists := len(interfacesResponse.NetworkInterfaces) > 0
+	c.trunkCache.Set(trunkId, exists)
+	return exists, nil
+}
+
+// Return a pointer to the value of a tag, or nil if the tag is missing or empty
+func getTagValue(tags map[string]string, key string) *string {
+	if value, ok := tags[key]; ok && value != "" {
+		return &value
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/elasticache"
+	elastiCacheTypes "github.com/aws/aws-sdk-go-v2/service/elasticache/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/hashicorp/go-set"
+	"strings"
+)
+
+const elastiCacheDescriptionPrefix = "ElastiCache "
+
+type AwsElastiCacheClient struct {
+	client           *elasticache.Client
+	cacheClusters    []elastiCacheTypes.CacheCluster
+	subnetGroups     map[string]*set.Set[string]
+	nodeIPs          map[string][]string
+	isCachePopulated bool
+}
+
+func NewAwsElastiCacheClient(cfg aws.Config) *AwsElastiCacheClient {
+	return &AwsElastiCacheClient{
+		client:        elasticache.NewFromConfig(cfg),
+		cacheClusters: make([]elastiCacheTypes.CacheCluster, 0),
+		subnetGroups:  make(map[string]*set.Set[string]),
+		nodeIPs:       make(map[string][]string),
+	}
+}
+
+// GetElastiCacheAttachment returns a pointer to an ElastiCacheAttachment for the network interface of an ElastiCache
+// node. Only the cache clusters whose cache subnet group contains the subnet of the network interface are considered.
+// A cluster is matched by the identifier from the description of the network interface, by the IP address of one of
+// its nodes, or by having the same security groups if this is the only cluster left. If no cluster is matched, the
+// cluster is considered removed only if no cluster with the identifier from the description exists anymore and the
+// network interface is not attached, otherwise it is unresolved, for example for serverless caches which are not cache
+// clusters. If the network interface does not belong to ElastiCache, the returned value is nil.
+func (c *AwsElastiCacheClient) GetElastiCacheAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.ElastiCacheAttachment, error) {
+	if eni.InterfaceType != ec2Types.NetworkInterfaceTypeInterface || eni.Description == nil ||
+		!strings.HasPrefix(*eni.Description, elastiCacheDescriptionPrefix) {
+		return nil, nil
+	}
+
+	if !c.isCachePopulated {
+		if err := c.populateCache(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	clusterId := strings.TrimSpace(strings.TrimPrefix(*eni.Description, elastiCacheDescriptionPrefix))
+	candidates := make([]elastiCacheTypes.CacheCluster, 0)
+	for _, cluster := range c.cacheClusters {
+		subnets, ok := c.subnetGroups[aws.ToString(cluster.CacheSubnetGroupName)]
+		if ok && eni.SubnetId != nil && subnets.Contains(*eni.SubnetId) {
+			candidates = append(candidates, cluster)
+		}
+	}
+
+	for _, cluster := range candidates {
+		if aws.ToString(cluster.CacheClusterId) == clusterId {
+			return newElastiCacheAttachment(cluster), nil
+		}
+	}
+
+	eniIPs := set.New[string](len(eni.PrivateIpAddresses))
+	for _, ip := range eni.PrivateIpAddresses {
+		if ip.PrivateIpAddress != nil {
+			eniIPs.Insert(*ip.PrivateIpAddress)
+		}
+	}
+	for _, cluster := range candidates {
+		for _, ip := range c.getNodeIPs(ctx, cluster) {
+			if eniIPs.Contains(ip) {
+				return newElastiCacheAttachment(cluster), nil
+			}
+		}
+	}
+
+	eniSecurityGroups := set.New[string](len(eni.Groups))
+	for _, sg := range eni.Groups {
+		eniSecurityGroups.Insert(aws.ToString(sg.GroupId))
+	}
+	sgMatches := make([]elastiCacheTypes.CacheCluster, 0)
+	for _, cluster := range candidates {
+		clusterSecurityGroups := set.New[string](len(cluster.SecurityGroups))
+		for _, membership := range cluster.SecurityGroups {
+			clusterSecurityGroups.Insert(aws.ToString(membership.SecurityGroupId))
+		}
+		if eniSecurityGroups.Equal(clusterSecurityGroups) {
+			sgMatches = append(sgMatches, cluster)
+		}
+	}
+	if len(sgMatches) == 1 {
+		return newElastiCacheAttachment(sgMatches[0]), nil
+	}
+
+	clusterExists := false
+	for _, cluster := range c.cacheClusters {
+		if aws.ToString(cluster.CacheClusterId) == clusterId {
+			clusterExists = true
+			break
+		}
+	}
+	isRemoved := clusterId != "" && !clusterExists && eni.Status == ec2Types.NetworkInterfaceStatusAvailable
+
+	return &coreTypes.ElastiCacheAttachment{
+		IsRemoved:      isRemoved,
+		IsUnresolved:   !isRemoved,
+		CacheClusterId: clusterId,
+	}, nil
+}
+
+func newElastiCacheAttachment(cluster elastiCacheTypes.CacheCluster) *coreTypes.ElastiCacheAttachment {
+	return &coreTypes.ElastiCacheAttachment{
+		IsRemoved:          false,
+		CacheClusterId:     aws.ToString(cluster.CacheClusterId),
+		ReplicationGroupId: cluster.ReplicationGroupId,
+		Engine:             cluster.Engine,
+	}
+}
+
+// Get the IP addresses of the nodes of a cache cluster by resolving their endpoints concurrently. Endpoints which
+// cannot be resolved are ignored
+func (c *AwsElastiCacheClient) getNodeIPs(ctx context.Context, cluster elastiCacheTypes.CacheCluster) []string {
+	clusterId := aws.ToString(cluster.CacheClusterId)
+	if ips, ok := c.nodeIPs[clusterId]; ok {
+		return ips
+	}
+
+	endpoints := make([]string, 0, len(cluster.CacheNodes))
+	for _, node := range cluster.CacheNodes {
+		if node.Endpoint != nil && node.Endpoint.Address != nil {
+			endpoints = append(endpoints, *node.Endpoint.Address)
+		}
+	}
+
+	ips := make([]string, 0)
+	for _, addresses := range lookupAllAddresses(ctx, endpoints) {
+		ips = append(ips, addresses...)
+	}
+
+	c.nodeIPs[clusterId] = ips
+	return ips
+}
+
+func (c *AwsElastiCacheClient) populateCache(ctx context.Context) error {
+	var nextToken *string
+	for {
+		clustersResponse, err := c.client.DescribeCacheClusters(ctx, &elasticache.DescribeCacheClustersInput{
+			Marker:            nextToken,
+			ShowCacheNodeInfo: aws.Bool(true),
+		})
+		if err != nil {
+			return err
+		}
+
+		c.cacheClusters = append(c.cacheClusters, clustersResponse.CacheClusters...)
+
+		if clustersResponse.Marker != nil {
+			nextToken = clustersResponse.Marker
+		} else {
+			break
+		}
+	}
+
+	nextToken = nil
+	for {
+		subnetGroupsResponse, err := c.client.DescribeCacheSubnetGroups(ctx, &elasticache.DescribeCacheSubnetGroupsInput{
+			Marker: nextToken,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, subnetGroup := range subnetGroupsResponse.CacheSubnetGroups {
+			subnets := set.New[string](len(subnetGroup.Subnets))
+			for _, subnet := range subnetGroup.Subnets {
+				subnets.Insert(aws.ToString(subnet.SubnetIdentifier))
+			}
+			c.subnetGroups[aws.ToString(subnetGroup.CacheSubnetGroupName)] = subnets
+		}
+
+		if subnetGroupsResponse.Marker != nil {
+			nextToken = subnetGroupsResponse.Marker
+		} else {
+			break
+		}
+	}
+
+	c.isCachePopulated = true
+	return nil
+}
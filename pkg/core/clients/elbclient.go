@@ -2,14 +2,24 @@ package clients
 
 import (
 	"context"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbTypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"regexp"
+	"strings"
 )
 
+// The prefixes used in the descriptions of the Network Interfaces for each load balancer type
+var elbTypesByPrefix = map[string]elbTypes.LoadBalancerTypeEnum{
+	"app": elbTypes.LoadBalancerTypeEnumApplication,
+	"net": elbTypes.LoadBalancerTypeEnumNetwork,
+	"gwy": elbTypes.LoadBalancerTypeEnumGateway,
+}
+
 type AwsElbClient struct {
 	client *elasticloadbalancingv2.Client
 	cache  cmap.ConcurrentMap[string, *coreTypes.ElbAttachment]
@@ -22,38 +32,67 @@ func NewAwsElbClient(cfg aws.Config) *AwsElbClient {
 	}
 }
 
-// GetELBAttachment returns a pointer to an ElbAttachment for the network interface. If there is no attachment found,
-// the returned value is a nil.
+// GetELBAttachment returns a pointer to an ElbAttachment for the network interface of an Application, Network or
+// Gateway Load Balancer. If there is no attachment found, the returned value is a nil.
 func (c *AwsElbClient) GetELBAttachment(ctx context.Context, eni ec2Types.NetworkInterface) (*coreTypes.ElbAttachment, error) {
-	regex := regexp.MustCompile("ELB app/(?P<elbName>.+)/(?P<elbId>([a-z]|[0-9])+)")
-	if eni.InterfaceType == ec2Types.NetworkInterfaceTypeInterface && eni.Description != nil {
-		match := regex.FindStringSubmatch(*eni.Description)
-		if len(match) > 0 {
-			elbName := match[regex.SubexpIndex("elbName")]
-
-			if cachedElb, ok := c.cache.Get(elbName); ok {
-				return cachedElb, nil
-			}
+	regex := regexp.MustCompile("ELB (?P<elbType>app|net|gwy)/(?P<elbName>.+)/(?P<elbId>([a-z]|[0-9])+)")
+	if !isElbInterfaceType(eni.InterfaceType) || eni.Description == nil {
+		return nil, nil
+	}
 
-			loadBalancers, err := c.client.DescribeLoadBalancers(ctx,
-				&elasticloadbalancingv2.DescribeLoadBalancersInput{Names: []string{elbName}})
-			if err != nil {
-				return nil, err
-			}
+	match := regex.FindStringSubmatch(*eni.Description)
+	if len(match) == 0 {
+		return nil, nil
+	}
+
+	elbType := match[regex.SubexpIndex("elbType")]
+	elbName := match[regex.SubexpIndex("elbName")]
+	elbId := match[regex.SubexpIndex("elbId")]
 
-			for _, elb := range loadBalancers.LoadBalancers {
-				attachment := &coreTypes.ElbAttachment{
-					IsRemoved: elb.LoadBalancerArn == nil,
-					Name:      elbName,
-					Arn:       elb.LoadBalancerArn,
-				}
+	// The ARN of a load balancer ends with its type, name and ID. A load balancer recreated with the same name gets a
+	// new ID, so the Network Interfaces of the previous one are not attributed to it
+	arnSuffix := strings.Join([]string{elbType, elbName, elbId}, "/")
+	if cachedElb, ok := c.cache.Get(arnSuffix); ok {
+		return cachedElb, nil
+	}
 
-				c.cache.Set(elbName, attachment)
+	attachment := &coreTypes.ElbAttachment{
+		IsRemoved: true,
+		Name:      elbName,
+		Type:      string(elbTypesByPrefix[elbType]),
+	}
 
-				// It is expected that we will have only one load balancer as a result
-				return attachment, nil
+	loadBalancers, err := c.client.DescribeLoadBalancers(ctx,
+		&elasticloadbalancingv2.DescribeLoadBalancersInput{Names: []string{elbName}})
+	if err != nil {
+		// Handle error in case the load balancer does not exist. Do not return this error to the caller
+		var notFoundErr *elbTypes.LoadBalancerNotFoundException
+		if !errors.As(err, &notFoundErr) {
+			return nil, err
+		}
+	} else {
+		for _, elb := range loadBalancers.LoadBalancers {
+			if elb.LoadBalancerArn != nil && strings.HasSuffix(*elb.LoadBalancerArn, "/"+arnSuffix) {
+				attachment.IsRemoved = false
+				attachment.Arn = elb.LoadBalancerArn
+				attachment.Type = string(elb.Type)
 			}
 		}
 	}
-	return nil, nil
+
+	c.cache.Set(arnSuffix, attachment)
+	return attachment, nil
+}
+
+// Application Load Balancers use regular Network Interfaces, while Network and Gateway Load Balancers have their own
+// interface types
+func isElbInterfaceType(interfaceType ec2Types.NetworkInterfaceType) bool {
+	switch interfaceType {
+	case ec2Types.NetworkInterfaceTypeInterface,
+		ec2Types.NetworkInterfaceTypeNetworkLoadBalancer,
+		ec2Types.NetworkInterfaceTypeGatewayLoadBalancer:
+		return true
+	default:
+		return false
+	}
 }
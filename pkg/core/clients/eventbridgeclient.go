@@ -0,0 +1,89 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventBridgeTypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+type AwsEventBridgeClient struct {
+	client *eventbridge.Client
+}
+
+func NewAwsEventBridgeClient(cfg aws.Config) *AwsEventBridgeClient {
+	return &AwsEventBridgeClient{
+		client: eventbridge.NewFromConfig(cfg),
+	}
+}
+
+// ListEventBuses returns every event bus, including the default one
+func (c *AwsEventBridgeClient) ListEventBuses(ctx context.Context) ([]eventBridgeTypes.EventBus, error) {
+	eventBuses := make([]eventBridgeTypes.EventBus, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListEventBuses(ctx, &eventbridge.ListEventBusesInput{NextToken: nextToken})
+		if err != nil {
+			return nil, err
+		}
+
+		eventBuses = append(eventBuses, response.EventBuses...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return eventBuses, nil
+}
+
+// ListRules returns every rule of an event bus
+func (c *AwsEventBridgeClient) ListRules(ctx context.Context, eventBusName string) ([]eventBridgeTypes.Rule, error) {
+	rules := make([]eventBridgeTypes.Rule, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListRules(ctx, &eventbridge.ListRulesInput{
+			EventBusName: &eventBusName,
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, response.Rules...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return rules, nil
+}
+
+// ListTargetsByRule returns the targets of a rule of an event bus
+func (c *AwsEventBridgeClient) ListTargetsByRule(ctx context.Context, eventBusName string,
+	ruleName string) ([]eventBridgeTypes.Target, error) {
+	targets := make([]eventBridgeTypes.Target, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListTargetsByRule(ctx, &eventbridge.ListTargetsByRuleInput{
+			EventBusName: &eventBusName,
+			Rule:         &ruleName,
+			NextToken:    nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, response.Targets...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return targets, nil
+}
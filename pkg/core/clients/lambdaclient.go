@@ -65,6 +65,54 @@ func (c *AwsLambdaClient) GetLambdaAttachment(ctx context.Context, eni ec2Types.
 	return nil, nil
 }
 
+// ListFunctions returns the configuration of every version of every Lambda function, including $LATEST
+func (c *AwsLambdaClient) ListFunctions(ctx context.Context) ([]lambdaTypes.FunctionConfiguration, error) {
+	functions := make([]lambdaTypes.FunctionConfiguration, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListFunctions(ctx, &lambda.ListFunctionsInput{
+			FunctionVersion: lambdaTypes.FunctionVersionAll,
+			Marker:          nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		functions = append(functions, response.Functions...)
+
+		if response.NextMarker != nil {
+			nextToken = response.NextMarker
+		} else {
+			break
+		}
+	}
+	return functions, nil
+}
+
+// ListAliases returns the aliases of a Lambda function
+func (c *AwsLambdaClient) ListAliases(ctx context.Context, fnName string) ([]lambdaTypes.AliasConfiguration, error) {
+	aliases := make([]lambdaTypes.AliasConfiguration, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListAliases(ctx, &lambda.ListAliasesInput{
+			FunctionName: &fnName,
+			Marker:       nextToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, response.Aliases...)
+
+		if response.NextMarker != nil {
+			nextToken = response.NextMarker
+		} else {
+			break
+		}
+	}
+	return aliases, nil
+}
+
 // Get the configuration for a Lambda function. If the function does not exist, the returned value will be nil
 func (c *AwsLambdaClient) getLambdaFunctionConfigByName(ctx context.Context, client *lambda.Client, fnName string) (*lambdaTypes.FunctionConfiguration, error) {
 	fnInput := lambda.GetFunctionInput{FunctionName: &fnName}
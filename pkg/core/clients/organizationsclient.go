@@ -0,0 +1,43 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	organizationsTypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+)
+
+type AwsOrganizationsClient struct {
+	client *organizations.Client
+}
+
+func NewAwsOrganizationsClient(cfg aws.Config) *AwsOrganizationsClient {
+	return &AwsOrganizationsClient{
+		client: organizations.NewFromConfig(cfg),
+	}
+}
+
+// ListActiveAccountIds returns the IDs of every active member account of the organization. It has to be called from
+// the management account or from a delegated administrator account.
+func (c *AwsOrganizationsClient) ListActiveAccountIds(ctx context.Context) ([]string, error) {
+	var nextToken *string = nil
+	accountIds := make([]string, 0)
+	for {
+		accountsResponse, err := c.client.ListAccounts(ctx, &organizations.ListAccountsInput{NextToken: nextToken})
+		if err != nil {
+			return nil, err
+		}
+		nextToken = accountsResponse.NextToken
+
+		for _, account := range accountsResponse.Accounts {
+			if account.Status == organizationsTypes.AccountStatusActive && account.Id != nil {
+				accountIds = append(accountIds, *account.Id)
+			}
+		}
+
+		if nextToken == nil {
+			break
+		}
+	}
+	return accountIds, nil
+}
@@ -8,58 +8,109 @@ import (
 	rdsTypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/hashicorp/go-set"
+	"strings"
 )
 
+const (
+	rdsDescription        = "RDSNetworkInterface"
+	rdsProxyDescription   = "Network interface for DBProxy"
+	rdsRequesterId        = "amazon-rds"
+	rdsDeletingStatus     = "deleting"
+	rdsDbInstanceEndpoint = "instance"
+	rdsDbProxyEndpoint    = "proxy"
+)
+
+// An RDS resource which can own a Network Interface, together with the addresses its endpoint resolves to
+type rdsEndpoint struct {
+	attachment     coreTypes.RdsAttachment
+	address        string
+	addresses      *set.Set[string]
+	subnetIds      *set.Set[string]
+	securityGroups *set.Set[string]
+}
+
 type AwsRdsClient struct {
 	client           *rds.Client
-	dbInstancesCache []rdsTypes.DBInstance
+	endpointsCache   []rdsEndpoint
+	isCachePopulated bool
 }
 
 func NewAwsRdsClient(cfg aws.Config) *AwsRdsClient {
 	return &AwsRdsClient{
-		client:           rds.NewFromConfig(cfg),
-		dbInstancesCache: make([]rdsTypes.DBInstance, 0),
+		client:         rds.NewFromConfig(cfg),
+		endpointsCache: make([]rdsEndpoint, 0),
 	}
 }
 
+// GetRdsAttachments returns the RDS resources which own the network interface of an RDS, Aurora, DocumentDB or Neptune
+// DB instance, or of an RDS Proxy. The network interface is matched precisely when the endpoint of a DB instance or of
+// a DB proxy resolves to one of its IP addresses. Otherwise, every DB instance and DB proxy which uses the subnet and
+// the same security groups as the network interface is returned. If nothing matches, for example for Aurora Serverless
+// v1 clusters which have no DB instances or for endpoints which cannot be resolved from this machine, the owner of the
+// network interface is unresolved. If the network interface does not belong to RDS, the returned slice is empty.
 func (c *AwsRdsClient) GetRdsAttachments(ctx context.Context, eni ec2Types.NetworkInterface) ([]coreTypes.RdsAttachment, error) {
 	rdsAttachments := make([]coreTypes.RdsAttachment, 0)
-	if eni.Description != nil && *eni.Description == "RDSNetworkInterface" {
-		if len(c.dbInstancesCache) <= 0 {
-			if err := c.populateDbInstanceCache(ctx); err != nil {
-				return nil, err
-			}
+	description := aws.ToString(eni.Description)
+	isProxyInterface := strings.HasPrefix(description, rdsProxyDescription)
+	if description != rdsDescription && !isProxyInterface && aws.ToString(eni.RequesterId) != rdsRequesterId {
+		return rdsAttachments, nil
+	}
+
+	if !c.isCachePopulated {
+		if err := c.populateEndpointsCache(ctx); err != nil {
+			return nil, err
 		}
+	}
 
-		dbSecurityGroups := make(map[string]*set.Set[string])
-		dbInstanceMap := make(map[string]rdsTypes.DBInstance)
-		for _, dbInstance := range c.dbInstancesCache {
-			dbInstanceMap[*dbInstance.DBInstanceIdentifier] = dbInstance
-			sgIds := set.New[string](len(dbInstance.VpcSecurityGroups))
-			for _, vpcSg := range dbInstance.VpcSecurityGroups {
-				sgIds.Insert(*vpcSg.VpcSecurityGroupId)
-			}
-			dbSecurityGroups[*dbInstance.DBInstanceIdentifier] = sgIds
+	eniAddresses := set.New[string](len(eni.PrivateIpAddresses))
+	for _, ip := range eni.PrivateIpAddresses {
+		if ip.PrivateIpAddress != nil {
+			eniAddresses.Insert(*ip.PrivateIpAddress)
 		}
+	}
 
-		eniSecurityGroups := set.New[string](len(eni.Groups))
-		for _, sg := range eni.Groups {
-			eniSecurityGroups.Insert(*sg.GroupId)
+	for _, endpoint := range c.endpointsCache {
+		if endpoint.addresses.Intersect(eniAddresses).Size() > 0 {
+			rdsAttachments = append(rdsAttachments, endpoint.attachment)
 		}
+	}
+	if len(rdsAttachments) > 0 {
+		return rdsAttachments, nil
+	}
 
-		for instanceIdentifier, sg := range dbSecurityGroups {
-			if eniSecurityGroups.Equal(sg) {
-				rdsAttachments = append(rdsAttachments, coreTypes.RdsAttachment{
-					IsRemoved:  false,
-					Identifier: instanceIdentifier,
-				})
-			}
+	eniSecurityGroups := set.New[string](len(eni.Groups))
+	for _, sg := range eni.Groups {
+		eniSecurityGroups.Insert(aws.ToString(sg.GroupId))
+	}
+
+	for _, endpoint := range c.endpointsCache {
+		if endpoint.subnetIds.Contains(aws.ToString(eni.SubnetId)) && endpoint.securityGroups.Equal(eniSecurityGroups) {
+			rdsAttachments = append(rdsAttachments, endpoint.attachment)
 		}
 	}
-	return rdsAttachments, nil
+	if len(rdsAttachments) > 0 {
+		return rdsAttachments, nil
+	}
+
+	unresolved := coreTypes.RdsAttachment{
+		IsUnresolved: true,
+		Type:         rdsDbInstanceEndpoint,
+	}
+	if isProxyInterface {
+		unresolved.Type = rdsDbProxyEndpoint
+		unresolved.Identifier = strings.TrimSpace(strings.TrimPrefix(description, rdsProxyDescription))
+	}
+	return append(rdsAttachments, unresolved), nil
 }
 
-func (c *AwsRdsClient) populateDbInstanceCache(ctx context.Context) error {
+// Fetch every DB instance and DB proxy, and resolve their endpoints concurrently. A DB instance which is being deleted,
+// or which is a member of a DB cluster being deleted, is considered removed
+func (c *AwsRdsClient) populateEndpointsCache(ctx context.Context) error {
+	clusterStatuses, err := c.describeDbClusterStatuses(ctx)
+	if err != nil {
+		return err
+	}
+
 	var nextToken *string
 	for {
 		dbInstanceResponse, err := c.client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{Marker: nextToken})
@@ -67,7 +118,38 @@ func (c *AwsRdsClient) populateDbInstanceCache(ctx context.Context) error {
 			return err
 		}
 
-		c.dbInstancesCache = append(c.dbInstancesCache, dbInstanceResponse.DBInstances...)
+		for _, dbInstance := range dbInstanceResponse.DBInstances {
+			isRemoved := aws.ToString(dbInstance.DBInstanceStatus) == rdsDeletingStatus
+			if dbInstance.DBClusterIdentifier != nil && clusterStatuses[*dbInstance.DBClusterIdentifier] == rdsDeletingStatus {
+				isRemoved = true
+			}
+
+			endpoint := rdsEndpoint{
+				attachment: coreTypes.RdsAttachment{
+					IsRemoved:         isRemoved,
+					Identifier:        aws.ToString(dbInstance.DBInstanceIdentifier),
+					Type:              rdsDbInstanceEndpoint,
+					Engine:            dbInstance.Engine,
+					ClusterIdentifier: dbInstance.DBClusterIdentifier,
+				},
+				addresses:      set.New[string](0),
+				subnetIds:      set.New[string](0),
+				securityGroups: set.New[string](len(dbInstance.VpcSecurityGroups)),
+			}
+			if dbInstance.Endpoint != nil && dbInstance.Endpoint.Address != nil {
+				endpoint.address = *dbInstance.Endpoint.Address
+			}
+			if dbInstance.DBSubnetGroup != nil {
+				for _, subnet := range dbInstance.DBSubnetGroup.Subnets {
+					endpoint.subnetIds.Insert(aws.ToString(subnet.SubnetIdentifier))
+				}
+			}
+			for _, vpcSg := range dbInstance.VpcSecurityGroups {
+				endpoint.securityGroups.Insert(aws.ToString(vpcSg.VpcSecurityGroupId))
+			}
+
+			c.endpointsCache = append(c.endpointsCache, endpoint)
+		}
 
 		if dbInstanceResponse.Marker != nil {
 			nextToken = dbInstanceResponse.Marker
@@ -76,5 +158,75 @@ func (c *AwsRdsClient) populateDbInstanceCache(ctx context.Context) error {
 		}
 	}
 
+	nextToken = nil
+	for {
+		dbProxyResponse, err := c.client.DescribeDBProxies(ctx, &rds.DescribeDBProxiesInput{Marker: nextToken})
+		if err != nil {
+			return err
+		}
+
+		for _, dbProxy := range dbProxyResponse.DBProxies {
+			endpoint := rdsEndpoint{
+				attachment: coreTypes.RdsAttachment{
+					IsRemoved:  dbProxy.Status == rdsTypes.DBProxyStatusDeleting,
+					Identifier: aws.ToString(dbProxy.DBProxyName),
+					Type:       rdsDbProxyEndpoint,
+					Engine:     dbProxy.EngineFamily,
+				},
+				addresses:      set.New[string](0),
+				subnetIds:      set.From[string](dbProxy.VpcSubnetIds),
+				securityGroups: set.From[string](dbProxy.VpcSecurityGroupIds),
+			}
+			if dbProxy.Endpoint != nil {
+				endpoint.address = *dbProxy.Endpoint
+			}
+
+			c.endpointsCache = append(c.endpointsCache, endpoint)
+		}
+
+		if dbProxyResponse.Marker != nil {
+			nextToken = dbProxyResponse.Marker
+		} else {
+			break
+		}
+	}
+
+	endpointAddresses := make([]string, 0, len(c.endpointsCache))
+	for _, endpoint := range c.endpointsCache {
+		if endpoint.address != "" {
+			endpointAddresses = append(endpointAddresses, endpoint.address)
+		}
+	}
+	resolved := lookupAllAddresses(ctx, endpointAddresses)
+	for _, endpoint := range c.endpointsCache {
+		endpoint.addresses.InsertSlice(resolved[endpoint.address])
+	}
+
+	c.isCachePopulated = true
 	return nil
 }
+
+// Return the status of every Aurora, DocumentDB and Neptune DB cluster
+func (c *AwsRdsClient) describeDbClusterStatuses(ctx context.Context) (map[string]string, error) {
+	statuses := make(map[string]string)
+
+	var nextToken *string
+	for {
+		dbClusterResponse, err := c.client.DescribeDBClusters(ctx, &rds.DescribeDBClustersInput{Marker: nextToken})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dbCluster := range dbClusterResponse.DBClusters {
+			statuses[aws.ToString(dbCluster.DBClusterIdentifier)] = aws.ToString(dbCluster.Status)
+		}
+
+		if dbClusterResponse.Marker != nil {
+			nextToken = dbClusterResponse.Marker
+		} else {
+			break
+		}
+	}
+
+	return statuses, nil
+}
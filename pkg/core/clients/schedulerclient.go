@@ -0,0 +1,48 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	schedulerTypes "github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+)
+
+type AwsSchedulerClient struct {
+	client *scheduler.Client
+}
+
+func NewAwsSchedulerClient(cfg aws.Config) *AwsSchedulerClient {
+	return &AwsSchedulerClient{
+		client: scheduler.NewFromConfig(cfg),
+	}
+}
+
+// ListSchedules returns the summaries of every EventBridge Scheduler schedule of every schedule group
+func (c *AwsSchedulerClient) ListSchedules(ctx context.Context) ([]schedulerTypes.ScheduleSummary, error) {
+	schedules := make([]schedulerTypes.ScheduleSummary, 0)
+	var nextToken *string
+	for {
+		response, err := c.client.ListSchedules(ctx, &scheduler.ListSchedulesInput{NextToken: nextToken})
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, response.Schedules...)
+
+		if response.NextToken != nil {
+			nextToken = response.NextToken
+		} else {
+			break
+		}
+	}
+	return schedules, nil
+}
+
+// GetSchedule returns a schedule together with its target, which is not part of the summaries of the schedules
+func (c *AwsSchedulerClient) GetSchedule(ctx context.Context, groupName string,
+	name string) (*scheduler.GetScheduleOutput, error) {
+	return c.client.GetSchedule(ctx, &scheduler.GetScheduleInput{
+		GroupName: &groupName,
+		Name:      &name,
+	})
+}
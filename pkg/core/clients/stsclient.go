@@ -0,0 +1,41 @@
+package clients
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// The session name used when assuming roles in other accounts
+const roleSessionName = "sg-ripper"
+
+type AwsStsClient struct {
+	client *sts.Client
+}
+
+func NewAwsStsClient(cfg aws.Config) *AwsStsClient {
+	return &AwsStsClient{
+		client: sts.NewFromConfig(cfg),
+	}
+}
+
+// GetCallerIdentity returns the ARN of the identity used for calling AWS, from which the account ID and the partition
+// can be retrieved
+func (c *AwsStsClient) GetCallerIdentity(ctx context.Context) (arn.ARN, error) {
+	identityResponse, err := c.client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return arn.ARN{}, err
+	}
+	return arn.Parse(aws.ToString(identityResponse.Arn))
+}
+
+// AssumeRoleCredentials returns a credentials provider for the role provided. The role is assumed lazily, when the
+// credentials are first needed, and the credentials are refreshed before they expire.
+func (c *AwsStsClient) AssumeRoleCredentials(roleArn string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(c.client, roleArn,
+		func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = roleSessionName
+		}))
+}
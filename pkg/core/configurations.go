@@ -0,0 +1,532 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	autoscalingTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	eventBridgeTypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	schedulerTypes "github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The number of configurations fetched at the same time when they can only be fetched one by one
+const configurationConcurrency = 8
+
+// A version of a launch template, identified by the ID of the launch template and the number of the version
+type launchTemplateVersionKey struct {
+	launchTemplateId string
+	version          string
+}
+
+// A Security Group referenced by its name, which is unique only within its VPC
+type securityGroupName struct {
+	vpcId string
+	name  string
+}
+
+// The Security Groups referenced by an Auto Scaling group, together with the VPC in which the ones referenced by name
+// are looked up
+type autoScalingGroupSecurityGroups struct {
+	vpcId          string
+	securityGroups []string
+}
+
+// Collects the Security Groups referenced by launch templates, launch configurations, Auto Scaling groups, ECS and Lambda
+// functions
+type configurationReferences struct {
+	ec2Client *clients.AwsEc2Client
+
+	references map[string][]coreTypes.ConfigurationReference
+	// References to Security Groups by name, which are used by launch templates and launch configurations of
+	// EC2-Classic and of the default VPC
+	namedReferences map[securityGroupName][]coreTypes.ConfigurationReference
+	// The VPC in which the Security Groups referenced by name are looked up, unless the VPC of the instances is known
+	defaultVpcId string
+	// The sources of configurations which could not be checked, together with their errors
+	unchecked []string
+
+	launchTemplateVersions map[launchTemplateVersionKey]ec2Types.LaunchTemplateVersion
+	launchTemplateIds      map[string]string
+	latestVersions         map[string]string
+	defaultVersions        map[string]string
+
+	launchConfigurations map[string]autoscalingTypes.LaunchConfiguration
+
+	autoScalingGroups map[string]autoScalingGroupSecurityGroups
+}
+
+// Get the configurations referencing each Security Group of a target: the latest and the default versions of every
+// launch template, every launch configuration, the launch template versions and the launch configurations used by
+// every Auto Scaling group, including the ones scaled to zero, the ECS services, scheduled tasks and capacity providers,
+// and every version and alias of every Lambda function. A source of configurations which cannot be checked, for example
+// because the access is denied, does not prevent the other ones from being checked, it is recorded instead
+func getConfigurationReferences(ctx context.Context, t target) *configurationReferences {
+	c := &configurationReferences{
+		ec2Client:              clients.NewAwsEc2Client(t.cfg),
+		references:             make(map[string][]coreTypes.ConfigurationReference),
+		namedReferences:        make(map[securityGroupName][]coreTypes.ConfigurationReference),
+		unchecked:              make([]string, 0),
+		launchTemplateVersions: make(map[launchTemplateVersionKey]ec2Types.LaunchTemplateVersion),
+		launchTemplateIds:      make(map[string]string),
+		latestVersions:         make(map[string]string),
+		defaultVersions:        make(map[string]string),
+		launchConfigurations:   make(map[string]autoscalingTypes.LaunchConfiguration),
+
+		autoScalingGroups: make(map[string]autoScalingGroupSecurityGroups),
+	}
+
+	defaultVpcId, err := c.ec2Client.GetDefaultVpcId(ctx)
+	c.check("default VPC", err)
+	c.defaultVpcId = defaultVpcId
+
+	versions, err := c.ec2Client.DescribeLaunchTemplateVersions(ctx, nil, nil, []string{"$Latest", "$Default"})
+	c.check("launch templates", err)
+	for _, version := range versions {
+		c.addLaunchTemplateVersion(version)
+		c.add(getLaunchTemplateSecurityGroups(version), c.defaultVpcId, coreTypes.ConfigurationReference{
+			Type:    coreTypes.LaunchTemplateReference,
+			Name:    aws.ToString(version.LaunchTemplateName),
+			Id:      aws.ToString(version.LaunchTemplateId),
+			Version: strconv.FormatInt(aws.ToInt64(version.VersionNumber), 10),
+		})
+	}
+
+	autoScalingClient := clients.NewAwsAutoScalingClient(t.cfg)
+	launchConfigurations, err := autoScalingClient.DescribeLaunchConfigurations(ctx)
+	c.check("launch configurations", err)
+	for _, launchConfiguration := range launchConfigurations {
+		c.launchConfigurations[aws.ToString(launchConfiguration.LaunchConfigurationName)] = launchConfiguration
+		c.add(launchConfiguration.SecurityGroups, c.defaultVpcId, coreTypes.ConfigurationReference{
+			Type: coreTypes.LaunchConfigurationReference,
+			Name: aws.ToString(launchConfiguration.LaunchConfigurationName),
+		})
+	}
+
+	autoScalingGroups, err := autoScalingClient.DescribeAutoScalingGroups(ctx)
+	c.check("Auto Scaling groups", err)
+	subnetVpcIds, err := c.ec2Client.GetSubnetVpcIds(ctx, getAutoScalingGroupSubnets(autoScalingGroups))
+	c.check("subnets of the Auto Scaling groups", err)
+	for _, group := range autoScalingGroups {
+		reference := coreTypes.ConfigurationReference{
+			Type: coreTypes.AutoScalingGroupReference,
+			Name: aws.ToString(group.AutoScalingGroupName),
+		}
+
+		securityGroups := make([]string, 0)
+		if group.LaunchConfigurationName != nil {
+			if launchConfiguration, ok := c.launchConfigurations[*group.LaunchConfigurationName]; ok {
+				securityGroups = append(securityGroups, launchConfiguration.SecurityGroups...)
+			}
+		}
+
+		for _, spec := range getLaunchTemplateSpecifications(group) {
+			version, ok, err := c.getLaunchTemplateVersion(ctx, spec)
+			if err != nil {
+				c.check(fmt.Sprintf("launch templates of the Auto Scaling group %s", reference.Name), err)
+				continue
+			}
+			if ok {
+				securityGroups = append(securityGroups, getLaunchTemplateSecurityGroups(version)...)
+			}
+		}
+
+		vpcId := c.getVpcId(group, subnetVpcIds)
+		c.autoScalingGroups[reference.Name] = autoScalingGroupSecurityGroups{vpcId: vpcId, securityGroups: securityGroups}
+		c.add(securityGroups, vpcId, reference)
+	}
+
+	c.addEcs(ctx, clients.NewAwsEcsClient(t.cfg), clients.NewAwsEventBridgeClient(t.cfg),
+		clients.NewAwsSchedulerClient(t.cfg))
+	c.addLambdaFunctions(ctx, clients.NewAwsLambdaClient(t.cfg))
+
+	return c
+}
+
+// Get the configurations referencing a Security Group, by its ID or by its name
+func (c *configurationReferences) get(sg ec2Types.SecurityGroup) []coreTypes.ConfigurationReference {
+	references := make([]coreTypes.ConfigurationReference, 0)
+	references = append(references, c.references[aws.ToString(sg.GroupId)]...)
+	name := securityGroupName{vpcId: aws.ToString(sg.VpcId), name: aws.ToString(sg.GroupName)}
+	for _, reference := range c.namedReferences[name] {
+		if !slices.Contains(references, reference) {
+			references = append(references, reference)
+		}
+	}
+	return references
+}
+
+// Record a source of configurations as unchecked if it failed
+func (c *configurationReferences) check(source string, err error) {
+	if err != nil {
+		c.unchecked = append(c.unchecked, fmt.Sprintf("%s: %v", source, err))
+	}
+}
+
+// Record the Security Groups of the network configuration of every ECS service and of their deployments, of every ECS
+// task started by an EventBridge rule or an EventBridge Scheduler schedule, and of the Auto Scaling groups of every ECS
+// capacity provider. Services scaled to zero and scheduled tasks have no Network Interface most of the time
+func (c *configurationReferences) addEcs(ctx context.Context, ecsClient *clients.AwsEcsClient,
+	eventBridgeClient *clients.AwsEventBridgeClient, schedulerClient *clients.AwsSchedulerClient) {
+	services, err := ecsClient.ListServices(ctx)
+	c.check("ECS services", err)
+	for _, service := range services {
+		if aws.ToString(service.Status) == "INACTIVE" {
+			continue
+		}
+
+		reference := coreTypes.ConfigurationReference{
+			Type: coreTypes.EcsServiceReference,
+			Name: aws.ToString(service.ServiceName),
+			Id:   aws.ToString(service.ServiceArn),
+		}
+		if service.NetworkConfiguration != nil && service.NetworkConfiguration.AwsvpcConfiguration != nil {
+			c.add(service.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups, "", reference)
+		}
+		for _, deployment := range service.Deployments {
+			if deployment.NetworkConfiguration != nil && deployment.NetworkConfiguration.AwsvpcConfiguration != nil {
+				c.add(deployment.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups, "", reference)
+			}
+		}
+	}
+
+	c.addEventBridgeRules(ctx, eventBridgeClient)
+	c.addSchedules(ctx, schedulerClient)
+
+	capacityProviders, err := ecsClient.DescribeCapacityProviders(ctx)
+	c.check("ECS capacity providers", err)
+	for _, capacityProvider := range capacityProviders {
+		if capacityProvider.AutoScalingGroupProvider == nil {
+			continue
+		}
+
+		// The name of the Auto Scaling group is the last part of its ARN
+		groupArn := aws.ToString(capacityProvider.AutoScalingGroupProvider.AutoScalingGroupArn)
+		groupName := groupArn[strings.LastIndex(groupArn, "/")+1:]
+		group := c.autoScalingGroups[groupName]
+		c.add(group.securityGroups, group.vpcId, coreTypes.ConfigurationReference{
+			Type: coreTypes.EcsCapacityProviderReference,
+			Name: aws.ToString(capacityProvider.Name),
+			Id:   groupName,
+		})
+	}
+}
+
+// Record the Security Groups of the ECS tasks started by the rules of every event bus. The targets of the rules are
+// listed concurrently, since they can only be listed rule by rule
+func (c *configurationReferences) addEventBridgeRules(ctx context.Context,
+	eventBridgeClient *clients.AwsEventBridgeClient) {
+	eventBuses, err := eventBridgeClient.ListEventBuses(ctx)
+	c.check("EventBridge event buses", err)
+
+	rules := make([]eventBridgeTypes.Rule, 0)
+	for _, eventBus := range eventBuses {
+		busRules, err := eventBridgeClient.ListRules(ctx, aws.ToString(eventBus.Name))
+		c.check(fmt.Sprintf("rules of the EventBridge event bus %s", aws.ToString(eventBus.Name)), err)
+		for _, rule := range busRules {
+			rule.EventBusName = eventBus.Name
+			rules = append(rules, rule)
+		}
+	}
+
+	targets, errs := mapConcurrently(rules, func(rule eventBridgeTypes.Rule) ([]eventBridgeTypes.Target, error) {
+		return eventBridgeClient.ListTargetsByRule(ctx, aws.ToString(rule.EventBusName), aws.ToString(rule.Name))
+	})
+	for i, rule := range rules {
+		if errs[i] != nil {
+			c.check(fmt.Sprintf("targets of the EventBridge rule %s", aws.ToString(rule.Name)), errs[i])
+			continue
+		}
+
+		for _, target := range targets[i] {
+			if target.EcsParameters == nil || target.EcsParameters.NetworkConfiguration == nil ||
+				target.EcsParameters.NetworkConfiguration.AwsvpcConfiguration == nil {
+				continue
+			}
+			c.add(target.EcsParameters.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups, "",
+				coreTypes.ConfigurationReference{
+					Type: coreTypes.EcsScheduledTaskReference,
+					Name: aws.ToString(rule.Name),
+					Id:   aws.ToString(rule.Arn),
+				})
+		}
+	}
+}
+
+// Record the Security Groups of the ECS tasks started by EventBridge Scheduler schedules. The summaries of the schedules
+// do not contain the network configuration, so the schedules targeting ECS clusters are fetched concurrently
+func (c *configurationReferences) addSchedules(ctx context.Context, schedulerClient *clients.AwsSchedulerClient) {
+	summaries, err := schedulerClient.ListSchedules(ctx)
+	c.check("EventBridge Scheduler schedules", err)
+
+	ecsSummaries := make([]schedulerTypes.ScheduleSummary, 0)
+	for _, summary := range summaries {
+		if summary.Target != nil && strings.Contains(aws.ToString(summary.Target.Arn), ":ecs:") {
+			ecsSummaries = append(ecsSummaries, summary)
+		}
+	}
+
+	schedules, errs := mapConcurrently(ecsSummaries,
+		func(summary schedulerTypes.ScheduleSummary) (*scheduler.GetScheduleOutput, error) {
+			return schedulerClient.GetSchedule(ctx, aws.ToString(summary.GroupName), aws.ToString(summary.Name))
+		})
+	for i, summary := range ecsSummaries {
+		name := aws.ToString(summary.GroupName) + "/" + aws.ToString(summary.Name)
+		if errs[i] != nil {
+			c.check(fmt.Sprintf("EventBridge Scheduler schedule %s", name), errs[i])
+			continue
+		}
+
+		target := schedules[i].Target
+		if target == nil || target.EcsParameters == nil || target.EcsParameters.NetworkConfiguration == nil ||
+			target.EcsParameters.NetworkConfiguration.AwsvpcConfiguration == nil {
+			continue
+		}
+		c.add(target.EcsParameters.NetworkConfiguration.AwsvpcConfiguration.SecurityGroups, "",
+			coreTypes.ConfigurationReference{
+				Type: coreTypes.EcsScheduleReference,
+				Name: name,
+				Id:   aws.ToString(summary.Arn),
+			})
+	}
+}
+
+// Call a function for every item concurrently, with at most configurationConcurrency calls at the same time. The
+// results and the errors are returned in the order of the items
+func mapConcurrently[T any, R any](items []T, fn func(T) (R, error)) ([]R, []error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, configurationConcurrency)
+	for i, item := range items {
+		i, item := i, item // capture values
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[i], errs[i] = fn(item)
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// Record the Security Groups of the VPC configuration of every version of every Lambda function, and of the aliases
+// pointing to these versions. Lambda functions release their Network Interfaces when they are idle, so their Security
+// Groups might not be used by any Network Interface
+func (c *configurationReferences) addLambdaFunctions(ctx context.Context, lambdaClient *clients.AwsLambdaClient) {
+	functions, err := lambdaClient.ListFunctions(ctx)
+	c.check("Lambda functions", err)
+
+	versionSecurityGroups := make(map[string]map[string][]string)
+	for _, function := range functions {
+		if function.VpcConfig == nil || len(function.VpcConfig.SecurityGroupIds) == 0 {
+			continue
+		}
+
+		fnName := aws.ToString(function.FunctionName)
+		if _, ok := versionSecurityGroups[fnName]; !ok {
+			versionSecurityGroups[fnName] = make(map[string][]string)
+		}
+		versionSecurityGroups[fnName][aws.ToString(function.Version)] = function.VpcConfig.SecurityGroupIds
+
+		c.add(function.VpcConfig.SecurityGroupIds, aws.ToString(function.VpcConfig.VpcId),
+			coreTypes.ConfigurationReference{
+				Type:    coreTypes.LambdaFunctionReference,
+				Name:    fnName,
+				Id:      aws.ToString(function.FunctionArn),
+				Version: aws.ToString(function.Version),
+			})
+	}
+
+	fnNames := make([]string, 0, len(versionSecurityGroups))
+	for fnName := range versionSecurityGroups {
+		fnNames = append(fnNames, fnName)
+	}
+	sort.Strings(fnNames)
+
+	for _, fnName := range fnNames {
+		versions := versionSecurityGroups[fnName]
+		aliases, err := lambdaClient.ListAliases(ctx, fnName)
+		if err != nil {
+			c.check(fmt.Sprintf("aliases of the Lambda function %s", fnName), err)
+			continue
+		}
+
+		for _, alias := range aliases {
+			aliasVersions := make([]string, 0)
+			if alias.RoutingConfig != nil {
+				for version := range alias.RoutingConfig.AdditionalVersionWeights {
+					aliasVersions = append(aliasVersions, version)
+				}
+				sort.Strings(aliasVersions)
+			}
+			aliasVersions = append([]string{aws.ToString(alias.FunctionVersion)}, aliasVersions...)
+
+			for _, version := range aliasVersions {
+				c.add(versions[version], "", coreTypes.ConfigurationReference{
+					Type:    coreTypes.LambdaFunctionReference,
+					Name:    fnName,
+					Id:      aws.ToString(alias.AliasArn),
+					Version: version,
+					Alias:   aws.ToString(alias.Name),
+				})
+			}
+		}
+	}
+}
+
+// Record a reference for every Security Group provided, skipping the references which were already recorded. Launch
+// templates and launch configurations of EC2-Classic and of the default VPC can reference Security Groups by name
+// instead of by ID, these names are looked up in the VPC provided
+func (c *configurationReferences) add(securityGroups []string, vpcId string,
+	reference coreTypes.ConfigurationReference) {
+	for _, sg := range securityGroups {
+		if !strings.HasPrefix(sg, "sg-") {
+			name := securityGroupName{vpcId: vpcId, name: sg}
+			if !slices.Contains(c.namedReferences[name], reference) {
+				c.namedReferences[name] = append(c.namedReferences[name], reference)
+			}
+			continue
+		}
+		if !slices.Contains(c.references[sg], reference) {
+			c.references[sg] = append(c.references[sg], reference)
+		}
+	}
+}
+
+// Get the VPC in which the instances of an Auto Scaling group are launched from the VPC of its subnets. The default VPC
+// is returned if the subnets are not known
+func (c *configurationReferences) getVpcId(group autoscalingTypes.AutoScalingGroup, subnetVpcIds map[string]string) string {
+	for _, subnetId := range getSubnets(group) {
+		if vpcId, ok := subnetVpcIds[subnetId]; ok {
+			return vpcId
+		}
+	}
+	return c.defaultVpcId
+}
+
+func (c *configurationReferences) addLaunchTemplateVersion(version ec2Types.LaunchTemplateVersion) {
+	launchTemplateId := aws.ToString(version.LaunchTemplateId)
+	versionNumber := aws.ToInt64(version.VersionNumber)
+	c.launchTemplateIds[aws.ToString(version.LaunchTemplateName)] = launchTemplateId
+	c.launchTemplateVersions[launchTemplateVersionKey{
+		launchTemplateId: launchTemplateId,
+		version:          strconv.FormatInt(versionNumber, 10),
+	}] = version
+
+	latest, err := strconv.ParseInt(c.latestVersions[launchTemplateId], 10, 64)
+	if err != nil || versionNumber > latest {
+		c.latestVersions[launchTemplateId] = strconv.FormatInt(versionNumber, 10)
+	}
+	if aws.ToBool(version.DefaultVersion) {
+		c.defaultVersions[launchTemplateId] = strconv.FormatInt(versionNumber, 10)
+	}
+}
+
+// Get the launch template version used by an Auto Scaling group. Versions other than the latest and the default ones
+// are fetched on demand. The returned flag is false if the launch template or the version does not exist
+func (c *configurationReferences) getLaunchTemplateVersion(ctx context.Context,
+	spec autoscalingTypes.LaunchTemplateSpecification) (ec2Types.LaunchTemplateVersion, bool, error) {
+	launchTemplateId := aws.ToString(spec.LaunchTemplateId)
+	if launchTemplateId == "" {
+		launchTemplateId = c.launchTemplateIds[aws.ToString(spec.LaunchTemplateName)]
+	}
+
+	// Every launch template which exists has a latest and a default version
+	version := aws.ToString(spec.Version)
+	switch version {
+	case "$Latest":
+		version = c.latestVersions[launchTemplateId]
+	case "", "$Default":
+		version = c.defaultVersions[launchTemplateId]
+	}
+
+	key := launchTemplateVersionKey{launchTemplateId: launchTemplateId, version: version}
+	if launchTemplateVersion, ok := c.launchTemplateVersions[key]; ok {
+		return launchTemplateVersion, true, nil
+	}
+	if launchTemplateId == "" || version == "" {
+		return ec2Types.LaunchTemplateVersion{}, false, nil
+	}
+
+	versions, err := c.ec2Client.DescribeLaunchTemplateVersions(ctx, &launchTemplateId, nil, []string{version})
+	if err != nil {
+		return ec2Types.LaunchTemplateVersion{}, false, err
+	}
+	for _, launchTemplateVersion := range versions {
+		c.launchTemplateVersions[key] = launchTemplateVersion
+		return launchTemplateVersion, true, nil
+	}
+	return ec2Types.LaunchTemplateVersion{}, false, nil
+}
+
+// Get the Security Groups of the instances launched from a launch template version, including the ones of their network
+// interfaces. Security Groups of EC2-Classic and of the default VPC can be referenced by name
+func getLaunchTemplateSecurityGroups(version ec2Types.LaunchTemplateVersion) []string {
+	if version.LaunchTemplateData == nil {
+		return nil
+	}
+
+	securityGroupIds := make([]string, 0, len(version.LaunchTemplateData.SecurityGroupIds))
+	securityGroupIds = append(securityGroupIds, version.LaunchTemplateData.SecurityGroupIds...)
+	securityGroupIds = append(securityGroupIds, version.LaunchTemplateData.SecurityGroups...)
+	for _, networkInterface := range version.LaunchTemplateData.NetworkInterfaces {
+		securityGroupIds = append(securityGroupIds, networkInterface.Groups...)
+	}
+	return securityGroupIds
+}
+
+// Get every launch template used by an Auto Scaling group, including the ones of its mixed instances policy
+func getLaunchTemplateSpecifications(group autoscalingTypes.AutoScalingGroup) []autoscalingTypes.LaunchTemplateSpecification {
+	specs := make([]autoscalingTypes.LaunchTemplateSpecification, 0)
+	if group.LaunchTemplate != nil {
+		specs = append(specs, *group.LaunchTemplate)
+	}
+	if group.MixedInstancesPolicy != nil && group.MixedInstancesPolicy.LaunchTemplate != nil {
+		launchTemplate := group.MixedInstancesPolicy.LaunchTemplate
+		if launchTemplate.LaunchTemplateSpecification != nil {
+			specs = append(specs, *launchTemplate.LaunchTemplateSpecification)
+		}
+		for _, override := range launchTemplate.Overrides {
+			if override.LaunchTemplateSpecification != nil {
+				specs = append(specs, *override.LaunchTemplateSpecification)
+			}
+		}
+	}
+	return specs
+}
+
+// Get the subnets in which an Auto Scaling group launches its instances
+func getSubnets(group autoscalingTypes.AutoScalingGroup) []string {
+	subnetIds := make([]string, 0)
+	for _, subnetId := range strings.Split(aws.ToString(group.VPCZoneIdentifier), ",") {
+		if subnetId = strings.TrimSpace(subnetId); subnetId != "" {
+			subnetIds = append(subnetIds, subnetId)
+		}
+	}
+	return subnetIds
+}
+
+// Get the subnets of every Auto Scaling group, without duplicates
+func getAutoScalingGroupSubnets(groups []autoscalingTypes.AutoScalingGroup) []string {
+	subnetIds := make([]string, 0)
+	for _, group := range groups {
+		for _, subnetId := range getSubnets(group) {
+			if !slices.Contains(subnetIds, subnetId) {
+				subnetIds = append(subnetIds, subnetId)
+			}
+		}
+	}
+	return subnetIds
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	autoscalingTypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConfigurationReferencesByName(t *testing.T) {
+	launchConfiguration := coreTypes.ConfigurationReference{Type: coreTypes.LaunchConfigurationReference, Name: "web"}
+	autoScalingGroup := coreTypes.ConfigurationReference{Type: coreTypes.AutoScalingGroupReference, Name: "web"}
+
+	c := &configurationReferences{
+		references:      make(map[string][]coreTypes.ConfigurationReference),
+		namedReferences: make(map[securityGroupName][]coreTypes.ConfigurationReference),
+		defaultVpcId:    "vpc-default",
+	}
+	c.add([]string{"web", "sg-a"}, c.defaultVpcId, launchConfiguration)
+	c.add([]string{"web"}, c.getVpcId(autoscalingTypes.AutoScalingGroup{
+		VPCZoneIdentifier: aws.String("subnet-a, subnet-b"),
+	}, map[string]string{"subnet-b": "vpc-app"}), autoScalingGroup)
+
+	tests := []struct {
+		name       string
+		sg         ec2Types.SecurityGroup
+		references []coreTypes.ConfigurationReference
+	}{
+		{
+			name:       "by ID",
+			sg:         ec2Types.SecurityGroup{GroupId: aws.String("sg-a"), GroupName: aws.String("api"), VpcId: aws.String("vpc-app")},
+			references: []coreTypes.ConfigurationReference{launchConfiguration},
+		},
+		{
+			name:       "by name in the default VPC",
+			sg:         ec2Types.SecurityGroup{GroupId: aws.String("sg-b"), GroupName: aws.String("web"), VpcId: aws.String("vpc-default")},
+			references: []coreTypes.ConfigurationReference{launchConfiguration},
+		},
+		{
+			name:       "by name in the VPC of the Auto Scaling group",
+			sg:         ec2Types.SecurityGroup{GroupId: aws.String("sg-c"), GroupName: aws.String("web"), VpcId: aws.String("vpc-app")},
+			references: []coreTypes.ConfigurationReference{autoScalingGroup},
+		},
+		{
+			name:       "same name in another VPC",
+			sg:         ec2Types.SecurityGroup{GroupId: aws.String("sg-d"), GroupName: aws.String("web"), VpcId: aws.String("vpc-other")},
+			references: []coreTypes.ConfigurationReference{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.references, c.get(tt.sg))
+		})
+	}
+}
+
+func TestGetVpcId(t *testing.T) {
+	c := &configurationReferences{defaultVpcId: "vpc-default"}
+	subnetVpcIds := map[string]string{"subnet-a": "vpc-app"}
+
+	tests := []struct {
+		name              string
+		vpcZoneIdentifier *string
+		vpcId             string
+	}{
+		{name: "no subnets", vpcId: "vpc-default"},
+		{name: "known subnet", vpcZoneIdentifier: aws.String("subnet-x,subnet-a"), vpcId: "vpc-app"},
+		{name: "unknown subnets", vpcZoneIdentifier: aws.String("subnet-x"), vpcId: "vpc-default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := autoscalingTypes.AutoScalingGroup{VPCZoneIdentifier: tt.vpcZoneIdentifier}
+			require.Equal(t, tt.vpcId, c.getVpcId(group, subnetVpcIds))
+		})
+	}
+}
@@ -2,7 +2,6 @@ package core
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/config"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/builders"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
@@ -13,19 +12,44 @@ import (
 // ListNetworkInterfaces returns a slice of NetworkInterfaceDetails based on the input ENI IDs and filters.
 // If the slice with the IDs is empty, all the network interfaces will be retrieved
 func ListNetworkInterfaces(ctx context.Context, eniIds []string, filters Filters, region string, profile string) ([]coreTypes.NetworkInterfaceDetails, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region), config.WithSharedConfigProfile(profile))
-	if err != nil {
+	return ListNetworkInterfacesInScope(ctx, eniIds, filters, NewScope(region, profile))
+}
+
+// ListNetworkInterfacesInScope returns a slice of NetworkInterfaceDetails from every account and region of the scope
+// based on the input ENI IDs and filters. The accounts and regions are scanned concurrently. If the slice with the IDs
+// is empty, all the network interfaces will be retrieved, otherwise an error is returned if any of the IDs is not found
+// anywhere. The accounts and regions which cannot be scanned are reported by a ScanError, returned together with the
+// Network Interfaces of the other ones
+func ListNetworkInterfacesInScope(ctx context.Context, eniIds []string, filters Filters, scope Scope) ([]coreTypes.NetworkInterfaceDetails, error) {
+	enis, _, scanErr := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.NetworkInterfaceDetails, error) {
+		return listNetworkInterfaces(ctx, t, eniIds, scope.Protection)
+	})
+	if _, err := asScanError(scanErr); err != nil {
 		return nil, err
 	}
 
-	ec2Client := clients.NewAwsEc2Client(cfg)
+	if missing := missingIds(eniIds, networkInterfaceIdsOf(enis)); len(missing) > 0 {
+		return nil, notFoundError("network interface(s)", missing, scanErr)
+	}
+
+	return applyEniFilters(enis, filters), scanErr
+}
+
+// List the Network Interfaces from the account and the region of the target, marking the protected ones. IDs which are
+// not found are ignored
+func listNetworkInterfaces(ctx context.Context, t target, eniIds []string,
+	protection Protection) ([]coreTypes.NetworkInterfaceDetails, error) {
+	ec2Client := clients.NewAwsEc2Client(t.cfg)
 
 	eniResultCh := make(chan utils.Result[[]ec2Types.NetworkInterface])
 	ec2Client.DescribeNetworkInterfaces(ctx, eniIds, eniResultCh)
 
 	enis := make([]coreTypes.NetworkInterfaceDetails, 0)
-	eniDetailsBuilder := builders.NewEniBuilder(cfg)
+	eniDetailsBuilder := builders.NewEniBuilder(t.cfg)
 	for eniResult := range eniResultCh {
+		if eniResult.Err != nil {
+			return nil, eniResult.Err
+		}
 		eniDetailsBatch, err := eniDetailsBuilder.FromRemoteInterfaces(ctx, eniResult.Data)
 		if err != nil {
 			return nil, err
@@ -33,33 +57,14 @@ func ListNetworkInterfaces(ctx context.Context, eniIds []string, filters Filters
 		enis = append(enis, eniDetailsBatch...)
 	}
 
-	return applyEniFilters(enis, filters), nil
+	protection.protectNetworkInterfaces(enis)
+	return enis, nil
 }
 
-// Apply Filters to the list of Network interface usages
-func applyEniFilters(enis []coreTypes.NetworkInterfaceDetails, filters Filters) []coreTypes.NetworkInterfaceDetails {
-	if filters.Status == All {
-		return enis
-	}
-
-	var filterFn func(eni coreTypes.NetworkInterfaceDetails) bool
-
-	switch filters.Status {
-	case Used:
-		filterFn = func(eni coreTypes.NetworkInterfaceDetails) bool {
-			return eni.IsInUse()
-		}
-	case Unused:
-		filterFn = func(eni coreTypes.NetworkInterfaceDetails) bool {
-			return !eni.IsInUse()
-		}
-	}
-
-	filteredEnis := make([]coreTypes.NetworkInterfaceDetails, 0)
+func networkInterfaceIdsOf(enis []coreTypes.NetworkInterfaceDetails) []string {
+	ids := make([]string, 0, len(enis))
 	for _, eni := range enis {
-		if filterFn(eni) {
-			filteredEnis = append(filteredEnis, eni)
-		}
+		ids = append(ids, eni.Id)
 	}
-	return filteredEnis
+	return ids
 }
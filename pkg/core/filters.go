@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	All SecurityGroupStatus = iota
+	Used
+	Unused
+)
+
+type SecurityGroupStatus int
+
+// Filters narrows down the Security Groups and Network Interfaces being listed or removed. Every filter which is set
+// has to match, empty filters match everything. Security Groups are matched against the subnets and the interface types
+// of the Network Interfaces using them
+type Filters struct {
+	Status SecurityGroupStatus
+	// VpcIds keeps only the resources from one of the VPCs
+	VpcIds []string
+	// SubnetIds keeps only the Network Interfaces from one of the subnets, and the Security Groups used by them
+	SubnetIds []string
+	// IncludeTags keeps only the resources matching every tag selector
+	IncludeTags []TagSelector
+	// ExcludeTags drops the resources matching any of the tag selectors
+	ExcludeTags []TagSelector
+	// NamePattern keeps only the resources with a matching name. The name of a Network Interface is its Name tag
+	NamePattern *regexp.Regexp
+	// DescriptionPattern keeps only the resources with a matching description
+	DescriptionPattern *regexp.Regexp
+	// InterfaceTypes keeps only the Network Interfaces of one of the types, and the Security Groups used by them
+	InterfaceTypes []string
+}
+
+// TagSelector matches resources having a tag with the key. If the value is set, the value of the tag has to be equal
+// to it
+type TagSelector struct {
+	Key   string
+	Value *string
+}
+
+// ParseTagSelector parses a tag selector in the form of "key=value", or "key" for matching any value
+func ParseTagSelector(selector string) (TagSelector, error) {
+	key, value, hasValue := strings.Cut(selector, "=")
+	if key == "" {
+		return TagSelector{}, fmt.Errorf("invalid tag selector %q, expected key=value or key", selector)
+	}
+	if !hasValue {
+		return TagSelector{Key: key}, nil
+	}
+	return TagSelector{Key: key, Value: &value}, nil
+}
+
+func (t TagSelector) String() string {
+	if t.Value == nil {
+		return t.Key
+	}
+	return t.Key + "=" + *t.Value
+}
+
+// Matches returns true if the tags contain the key of the selector with the expected value
+func (t TagSelector) Matches(tags map[string]string) bool {
+	value, ok := tags[t.Key]
+	if !ok {
+		return false
+	}
+	return t.Value == nil || *t.Value == value
+}
+
+// Apply Filters to the list of Security Group usages
+func applyFilters(groups []coreTypes.SecurityGroupDetails, filters Filters) []coreTypes.SecurityGroupDetails {
+	filteredGroups := make([]coreTypes.SecurityGroupDetails, 0)
+	for _, sg := range groups {
+		if filters.matchesSecurityGroup(sg) {
+			filteredGroups = append(filteredGroups, sg)
+		}
+	}
+	return filteredGroups
+}
+
+// Apply Filters to the list of Network interface usages
+func applyEniFilters(enis []coreTypes.NetworkInterfaceDetails, filters Filters) []coreTypes.NetworkInterfaceDetails {
+	filteredEnis := make([]coreTypes.NetworkInterfaceDetails, 0)
+	for _, eni := range enis {
+		if filters.matchesNetworkInterface(eni) {
+			filteredEnis = append(filteredEnis, eni)
+		}
+	}
+	return filteredEnis
+}
+
+func (f Filters) matchesSecurityGroup(sg coreTypes.SecurityGroupDetails) bool {
+	// Security Groups referenced only by other unused Security Groups are unused as well
+	isInUse := sg.IsInUse() && !sg.IsRemovableAsSet()
+	switch f.Status {
+	case Used:
+		if !isInUse {
+			return false
+		}
+	case Unused:
+		if isInUse {
+			return false
+		}
+	}
+
+	if len(f.SubnetIds) > 0 && !slices.ContainsFunc(sg.UsedBy, func(eni coreTypes.NetworkInterfaceDetails) bool {
+		return slices.Contains(f.SubnetIds, eni.SubnetId)
+	}) {
+		return false
+	}
+
+	if len(f.InterfaceTypes) > 0 && !slices.ContainsFunc(sg.UsedBy, func(eni coreTypes.NetworkInterfaceDetails) bool {
+		return slices.Contains(f.InterfaceTypes, eni.Type)
+	}) {
+		return false
+	}
+
+	return f.matchesCommon(sg.VpcId, sg.Tags, sg.Name, sg.Description)
+}
+
+func (f Filters) matchesNetworkInterface(eni coreTypes.NetworkInterfaceDetails) bool {
+	switch f.Status {
+	case Used:
+		if !eni.IsInUse() {
+			return false
+		}
+	case Unused:
+		if eni.IsInUse() {
+			return false
+		}
+	}
+
+	if len(f.SubnetIds) > 0 && !slices.Contains(f.SubnetIds, eni.SubnetId) {
+		return false
+	}
+
+	if len(f.InterfaceTypes) > 0 && !slices.Contains(f.InterfaceTypes, eni.Type) {
+		return false
+	}
+
+	description := ""
+	if eni.Description != nil {
+		description = *eni.Description
+	}
+
+	return f.matchesCommon(eni.VpcId, eni.Tags, eni.Tags["Name"], description)
+}
+
+// Match the properties shared by Security Groups and Network Interfaces
+func (f Filters) matchesCommon(vpcId string, tags map[string]string, name string, description string) bool {
+	if len(f.VpcIds) > 0 && !slices.Contains(f.VpcIds, vpcId) {
+		return false
+	}
+
+	for _, selector := range f.IncludeTags {
+		if !selector.Matches(tags) {
+			return false
+		}
+	}
+
+	for _, selector := range f.ExcludeTags {
+		if selector.Matches(tags) {
+			return false
+		}
+	}
+
+	if f.NamePattern != nil && !f.NamePattern.MatchString(name) {
+		return false
+	}
+
+	if f.DescriptionPattern != nil && !f.DescriptionPattern.MatchString(description) {
+		return false
+	}
+
+	return true
+}
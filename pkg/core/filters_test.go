@@ -0,0 +1,134 @@
+package core
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestParseTagSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		expected TagSelector
+		hasError bool
+	}{
+		{selector: "team=payments", expected: TagSelector{Key: "team", Value: aws.String("payments")}},
+		{selector: "keep", expected: TagSelector{Key: "keep"}},
+		{selector: "keep=", expected: TagSelector{Key: "keep", Value: aws.String("")}},
+		{selector: "url=a=b", expected: TagSelector{Key: "url", Value: aws.String("a=b")}},
+		{selector: "sg-ripper:protect=true", expected: TagSelector{Key: "sg-ripper:protect", Value: aws.String("true")}},
+		{selector: "", hasError: true},
+		{selector: "=payments", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := ParseTagSelector(tt.selector)
+			if tt.hasError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, selector)
+		})
+	}
+}
+
+func TestTagSelectorMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		tags     map[string]string
+		matches  bool
+	}{
+		{name: "same value", selector: "team=payments", tags: map[string]string{"team": "payments"}, matches: true},
+		{name: "different value", selector: "team=payments", tags: map[string]string{"team": "orders"}},
+		{name: "missing key", selector: "team=payments", tags: map[string]string{"owner": "payments"}},
+		{name: "any value", selector: "team", tags: map[string]string{"team": "orders"}, matches: true},
+		{name: "empty value", selector: "team=", tags: map[string]string{"team": ""}, matches: true},
+		{name: "no tags", selector: "team", tags: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseTagSelector(tt.selector)
+			require.NoError(t, err)
+			require.Equal(t, tt.matches, selector.Matches(tt.tags))
+		})
+	}
+}
+
+func TestMatchesSecurityGroup(t *testing.T) {
+	mustParse := func(selector string) TagSelector {
+		s, err := ParseTagSelector(selector)
+		require.NoError(t, err)
+		return s
+	}
+
+	sg := *coreTypes.NewSecurityGroup("payments-api", "sg-a", "API of the payments service",
+		[]coreTypes.NetworkInterfaceDetails{{Id: "eni-1", SubnetId: "subnet-1", Type: "interface"}}, nil, "vpc-1")
+	sg.Tags = map[string]string{"team": "payments"}
+
+	tests := []struct {
+		name    string
+		filters Filters
+		matches bool
+	}{
+		{name: "no filters", filters: Filters{}, matches: true},
+		{name: "used", filters: Filters{Status: Used}, matches: true},
+		{name: "unused", filters: Filters{Status: Unused}},
+		{name: "VPC", filters: Filters{VpcIds: []string{"vpc-2", "vpc-1"}}, matches: true},
+		{name: "other VPC", filters: Filters{VpcIds: []string{"vpc-2"}}},
+		{name: "subnet of a Network Interface", filters: Filters{SubnetIds: []string{"subnet-1"}}, matches: true},
+		{name: "other subnet", filters: Filters{SubnetIds: []string{"subnet-2"}}},
+		{name: "type of a Network Interface", filters: Filters{InterfaceTypes: []string{"interface"}}, matches: true},
+		{name: "other type", filters: Filters{InterfaceTypes: []string{"lambda"}}},
+		{name: "included tag", filters: Filters{IncludeTags: []TagSelector{mustParse("team")}}, matches: true},
+		{
+			name:    "one of the included tags is missing",
+			filters: Filters{IncludeTags: []TagSelector{mustParse("team"), mustParse("owner")}},
+		},
+		{name: "excluded tag", filters: Filters{ExcludeTags: []TagSelector{mustParse("team=payments")}}},
+		{
+			name:    "excluded tag with another value",
+			filters: Filters{ExcludeTags: []TagSelector{mustParse("team=orders")}},
+			matches: true,
+		},
+		{name: "name", filters: Filters{NamePattern: regexp.MustCompile("^payments-")}, matches: true},
+		{name: "other name", filters: Filters{NamePattern: regexp.MustCompile("^orders-")}},
+		{name: "description", filters: Filters{DescriptionPattern: regexp.MustCompile("payments")}, matches: true},
+		{name: "other description", filters: Filters{DescriptionPattern: regexp.MustCompile("orders")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.matches, tt.filters.matchesSecurityGroup(sg))
+		})
+	}
+}
+
+func TestMatchesSecurityGroupRemovableAsSet(t *testing.T) {
+	referenced := *coreTypes.NewSecurityGroup("payments-api", "sg-a", "", nil, []string{"sg-b"}, "vpc-1")
+	removableAsSet := referenced
+	removableAsSet.RemovalSet = []string{"sg-b", "sg-a"}
+
+	tests := []struct {
+		name    string
+		sg      coreTypes.SecurityGroupDetails
+		status  SecurityGroupStatus
+		matches bool
+	}{
+		{name: "referenced is used", sg: referenced, status: Used, matches: true},
+		{name: "referenced is not unused", sg: referenced, status: Unused},
+		{name: "removable as a set is unused", sg: removableAsSet, status: Unused, matches: true},
+		{name: "removable as a set is not used", sg: removableAsSet, status: Used},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.matches, Filters{Status: tt.status}.matchesSecurityGroup(tt.sg))
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package graph
+
+import (
+	"fmt"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+)
+
+// Kinds of the nodes of the graph
+const (
+	SecurityGroupNode    = "security-group"
+	NetworkInterfaceNode = "network-interface"
+	ResourceNode         = "resource"
+	ConfigurationNode    = "configuration"
+)
+
+// Kinds of the edges of the graph
+const (
+	// ReferencedByEdge goes from a Security Group to a Security Group with a rule referencing it
+	ReferencedByEdge = "referenced-by"
+	// UsedByEdge goes from a Security Group to a Network Interface using it
+	UsedByEdge = "used-by"
+	// OwnedByEdge goes from a Network Interface to the resource owning it
+	OwnedByEdge = "owned-by"
+	// ConfiguredByEdge goes from a Security Group to a configuration referencing it
+	ConfiguredByEdge = "configured-by"
+)
+
+// Node is a Security Group, a Network Interface, a resource owning a Network Interface or a configuration referencing
+// a Security Group. The ID is unique in the graph and it contains the account and the region of the node, so resources
+// of different accounts and regions which have the same name are different nodes
+type Node struct {
+	Id        string
+	Kind      string
+	Label     string
+	AccountId string
+	Region    string
+	IsRemoved bool
+}
+
+type Edge struct {
+	From string
+	To   string
+	Kind string
+}
+
+// Graph holds the relationships of Security Groups discovered by sg-ripper. Nodes and edges are kept in the order in
+// which they were added, so the rendered output is stable
+type Graph struct {
+	Nodes   []Node
+	Edges   []Edge
+	indexes map[string]int
+	edges   map[Edge]bool
+}
+
+// NewGraph builds the graph of the Security Groups provided, of the Security Groups referencing them, of the Network
+// Interfaces using them together with the resources owning them, and of the configurations referencing them
+func NewGraph(groups []coreTypes.SecurityGroupDetails) *Graph {
+	g := &Graph{
+		Nodes:   make([]Node, 0),
+		Edges:   make([]Edge, 0),
+		indexes: make(map[string]int),
+		edges:   make(map[Edge]bool),
+	}
+
+	for _, sg := range groups {
+		g.addNode(Node{
+			Id:        nodeId("sg", sg.AccountId, sg.Region, sg.Id),
+			Kind:      SecurityGroupNode,
+			Label:     fmt.Sprintf("%s (%s)", sg.Name, sg.Id),
+			AccountId: sg.AccountId,
+			Region:    sg.Region,
+		})
+	}
+
+	for _, sg := range groups {
+		sgNodeId := nodeId("sg", sg.AccountId, sg.Region, sg.Id)
+
+		// The rules referencing a Security Group belong to Security Groups of the same account and region
+		for _, referencingId := range sg.RuleReferences {
+			referencingNodeId := g.addSecurityGroupReference(referencingId, sg.AccountId, sg.Region)
+			g.addEdge(Edge{From: sgNodeId, To: referencingNodeId, Kind: ReferencedByEdge})
+		}
+
+		// The rules of a Security Group can reference Security Groups of other accounts, for example through a VPC
+		// peering, which are not reported as rule references by the account owning them
+		for _, rule := range sg.Rules {
+			if rule.ReferencedGroupId == nil || *rule.ReferencedGroupId == sg.Id {
+				continue
+			}
+			accountId := sg.AccountId
+			if rule.ReferencedGroupOwnerId != nil {
+				accountId = *rule.ReferencedGroupOwnerId
+			}
+			referencedNodeId := g.addSecurityGroupReference(*rule.ReferencedGroupId, accountId, sg.Region)
+			g.addEdge(Edge{From: referencedNodeId, To: sgNodeId, Kind: ReferencedByEdge})
+		}
+
+		for _, eni := range sg.UsedBy {
+			eniNodeId := nodeId("eni", eni.AccountId, eni.Region, eni.Id)
+			g.addNode(Node{
+				Id:        eniNodeId,
+				Kind:      NetworkInterfaceNode,
+				Label:     fmt.Sprintf("%s (%s)", eni.Id, eni.PrivateIPAddress),
+				AccountId: eni.AccountId,
+				Region:    eni.Region,
+			})
+			g.addEdge(Edge{From: sgNodeId, To: eniNodeId, Kind: UsedByEdge})
+
+			for _, owner := range getOwnerNodes(eni) {
+				g.addNode(owner)
+				g.addEdge(Edge{From: eniNodeId, To: owner.Id, Kind: OwnedByEdge})
+			}
+		}
+
+		for _, reference := range sg.ConfigurationReferences {
+			configurationNode := getConfigurationNode(reference, sg)
+			g.addNode(configurationNode)
+			g.addEdge(Edge{From: sgNodeId, To: configurationNode.Id, Kind: ConfiguredByEdge})
+		}
+	}
+
+	return g
+}
+
+// Add a node unless a node with the same ID already exists
+func (g *Graph) addNode(node Node) {
+	if _, ok := g.indexes[node.Id]; ok {
+		return
+	}
+	g.indexes[node.Id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, node)
+}
+
+func (g *Graph) addEdge(edge Edge) {
+	if g.edges[edge] {
+		return
+	}
+	g.edges[edge] = true
+	g.Edges = append(g.Edges, edge)
+}
+
+// Add the node of a Security Group referenced by a rule, which might not be part of the groups provided, in which case
+// only its ID is known. The ID of the node is returned
+func (g *Graph) addSecurityGroupReference(sgId string, accountId string, region string) string {
+	id := nodeId("sg", accountId, region, sgId)
+	g.addNode(Node{
+		Id:        id,
+		Kind:      SecurityGroupNode,
+		Label:     sgId,
+		AccountId: accountId,
+		Region:    region,
+	})
+	return id
+}
+
+// Get the ID of a node from its kind, its location and the ID of the resource, which is unique only in its account and
+// region
+func nodeId(kind string, accountId string, region string, id string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", kind, accountId, region, id)
+}
+
+// Get the nodes of the resources owning a Network Interface
+func getOwnerNodes(eni coreTypes.NetworkInterfaceDetails) []Node {
+	owners := make([]Node, 0)
+	for _, owner := range eni.Owners() {
+		owners = append(owners, Node{
+			Id:        nodeId(owner.Kind, eni.AccountId, eni.Region, owner.Id),
+			Kind:      ResourceNode,
+			Label:     owner.Label,
+			AccountId: eni.AccountId,
+			Region:    eni.Region,
+			IsRemoved: owner.IsRemoved,
+		})
+	}
+	return owners
+}
+
+func getConfigurationNode(reference coreTypes.ConfigurationReference, sg coreTypes.SecurityGroupDetails) Node {
+	return Node{
+		Id: nodeId(reference.Type, sg.AccountId, sg.Region, fmt.Sprintf("%s:%s:%s:%s", reference.Id, reference.Name,
+			reference.Version, reference.Alias)),
+		Kind:      ConfigurationNode,
+		Label:     reference.Label(),
+		AccountId: sg.AccountId,
+		Region:    sg.Region,
+	}
+}
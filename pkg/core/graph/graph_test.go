@@ -0,0 +1,76 @@
+package graph
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestSecurityGroup(id string, accountId string, usedBy []coreTypes.NetworkInterfaceDetails,
+	ruleReferences ...string) coreTypes.SecurityGroupDetails {
+	sg := *coreTypes.NewSecurityGroup("web", id, "", usedBy, ruleReferences, "vpc-1")
+	sg.AccountId = accountId
+	sg.Region = "eu-west-1"
+	return sg
+}
+
+func TestNewGraph(t *testing.T) {
+	instanceEni := coreTypes.NetworkInterfaceDetails{
+		Id:               "eni-1",
+		AccountId:        "111111111111",
+		Region:           "eu-west-1",
+		PrivateIPAddress: "10.0.0.1",
+		EC2Attachment:    &coreTypes.Ec2Attachment{InstanceId: "i-1"},
+	}
+	lambdaEni := coreTypes.NetworkInterfaceDetails{
+		Id:               "eni-2",
+		AccountId:        "111111111111",
+		Region:           "eu-west-1",
+		PrivateIPAddress: "10.0.0.2",
+		LambdaAttachment: &coreTypes.LambdaAttachment{IsRemoved: true, Name: "orders"},
+	}
+
+	referenced := newTestSecurityGroup("sg-a", "111111111111",
+		[]coreTypes.NetworkInterfaceDetails{instanceEni, lambdaEni}, "sg-b", "sg-x")
+	referenced.ConfigurationReferences = []coreTypes.ConfigurationReference{
+		{Type: coreTypes.LaunchTemplateReference, Name: "web", Id: "lt-1", Version: "2"},
+	}
+	referencing := newTestSecurityGroup("sg-b", "111111111111", []coreTypes.NetworkInterfaceDetails{instanceEni})
+	otherAccount := newTestSecurityGroup("sg-a", "222222222222", nil)
+
+	g := NewGraph([]coreTypes.SecurityGroupDetails{referenced, referencing, otherAccount})
+
+	require.Equal(t, []Node{
+		{Id: "sg:111111111111:eu-west-1:sg-a", Kind: SecurityGroupNode, Label: "web (sg-a)",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "sg:111111111111:eu-west-1:sg-b", Kind: SecurityGroupNode, Label: "web (sg-b)",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "sg:222222222222:eu-west-1:sg-a", Kind: SecurityGroupNode, Label: "web (sg-a)",
+			AccountId: "222222222222", Region: "eu-west-1"},
+		{Id: "sg:111111111111:eu-west-1:sg-x", Kind: SecurityGroupNode, Label: "sg-x",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "eni:111111111111:eu-west-1:eni-1", Kind: NetworkInterfaceNode, Label: "eni-1 (10.0.0.1)",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "ec2:111111111111:eu-west-1:i-1", Kind: ResourceNode, Label: "EC2 instance i-1",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "eni:111111111111:eu-west-1:eni-2", Kind: NetworkInterfaceNode, Label: "eni-2 (10.0.0.2)",
+			AccountId: "111111111111", Region: "eu-west-1"},
+		{Id: "lambda:111111111111:eu-west-1:orders", Kind: ResourceNode, Label: "Lambda function orders (removed)",
+			AccountId: "111111111111", Region: "eu-west-1", IsRemoved: true},
+		{Id: "launch-template:111111111111:eu-west-1:lt-1:web:2:", Kind: ConfigurationNode,
+			Label: "launch-template web version 2", AccountId: "111111111111", Region: "eu-west-1"},
+	}, g.Nodes)
+
+	require.Equal(t, []Edge{
+		{From: "sg:111111111111:eu-west-1:sg-a", To: "sg:111111111111:eu-west-1:sg-b", Kind: ReferencedByEdge},
+		{From: "sg:111111111111:eu-west-1:sg-a", To: "sg:111111111111:eu-west-1:sg-x", Kind: ReferencedByEdge},
+		{From: "sg:111111111111:eu-west-1:sg-a", To: "eni:111111111111:eu-west-1:eni-1", Kind: UsedByEdge},
+		{From: "eni:111111111111:eu-west-1:eni-1", To: "ec2:111111111111:eu-west-1:i-1", Kind: OwnedByEdge},
+		{From: "sg:111111111111:eu-west-1:sg-a", To: "eni:111111111111:eu-west-1:eni-2", Kind: UsedByEdge},
+		{From: "eni:111111111111:eu-west-1:eni-2", To: "lambda:111111111111:eu-west-1:orders",
+			Kind: OwnedByEdge},
+		{From: "sg:111111111111:eu-west-1:sg-a", To: "launch-template:111111111111:eu-west-1:lt-1:web:2:",
+			Kind: ConfiguredByEdge},
+		{From: "sg:111111111111:eu-west-1:sg-b", To: "eni:111111111111:eu-west-1:eni-1", Kind: UsedByEdge},
+	}, g.Edges)
+}
@@ -0,0 +1,206 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatGraphML = "graphml"
+)
+
+// ValidateFormat returns an error if the graph format provided is not supported
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatDOT, FormatMermaid, FormatGraphML:
+		return nil
+	default:
+		return fmt.Errorf("unsupported graph format %q, expected one of: %s, %s, %s", format, FormatDOT,
+			FormatMermaid, FormatGraphML)
+	}
+}
+
+// Write renders the graph in the format provided, which is one of FormatDOT, FormatMermaid or FormatGraphML
+func Write(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatMermaid:
+		return WriteMermaid(w, g)
+	case FormatGraphML:
+		return WriteGraphML(w, g)
+	case FormatDOT:
+		return WriteDOT(w, g)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// WriteDOT renders the graph as a Graphviz digraph
+func WriteDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph \"sg-ripper\" {\n")
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range g.Nodes {
+		attributes := fmt.Sprintf("label=%s, shape=%s", strconv.Quote(node.Label), dotShapes[node.Kind])
+		if node.IsRemoved {
+			attributes += ", style=dashed, color=orange"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(node.Id), attributes)
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To),
+			strconv.Quote(edgeLabels[edge.Kind]))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid renders the graph as a Mermaid flowchart. Mermaid does not accept arbitrary node IDs, so the nodes are
+// numbered in the order of the graph
+func WriteMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		shape := mermaidShapes[node.Kind]
+		fmt.Fprintf(&b, "  n%d%s\"%s\"%s\n", i, shape[0], escapeMermaid(node.Label), shape[1])
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  n%d -->|%s| n%d\n", g.indexes[edge.From], edgeLabels[edge.Kind], g.indexes[edge.To])
+	}
+
+	removed := make([]string, 0)
+	for i, node := range g.Nodes {
+		if node.IsRemoved {
+			removed = append(removed, fmt.Sprintf("n%d", i))
+		}
+	}
+	if len(removed) > 0 {
+		b.WriteString("  classDef removed stroke:orange,stroke-dasharray:5 5\n")
+		fmt.Fprintf(&b, "  class %s removed\n", strings.Join(removed, ","))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	Id       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	Id          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	Id   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML renders the graph as GraphML, with the kind, the label, the location and the removal state of every
+// node as attributes
+func WriteGraphML(w io.Writer, g *Graph) error {
+	document := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{Id: "kind", For: "node", AttrName: "kind", AttrType: "string"},
+			{Id: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{Id: "accountId", For: "node", AttrName: "accountId", AttrType: "string"},
+			{Id: "region", For: "node", AttrName: "region", AttrType: "string"},
+			{Id: "isRemoved", For: "node", AttrName: "isRemoved", AttrType: "boolean"},
+			{Id: "edgeKind", For: "edge", AttrName: "kind", AttrType: "string"},
+		},
+		Graph: graphMLGraph{
+			Id:          "sg-ripper",
+			EdgeDefault: "directed",
+			Nodes:       make([]graphMLNode, 0, len(g.Nodes)),
+			Edges:       make([]graphMLEdge, 0, len(g.Edges)),
+		},
+	}
+
+	for _, node := range g.Nodes {
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			Id: node.Id,
+			Data: []graphMLData{
+				{Key: "kind", Value: node.Kind},
+				{Key: "label", Value: node.Label},
+				{Key: "accountId", Value: node.AccountId},
+				{Key: "region", Value: node.Region},
+				{Key: "isRemoved", Value: strconv.FormatBool(node.IsRemoved)},
+			},
+		})
+	}
+	for _, edge := range g.Edges {
+		document.Graph.Edges = append(document.Graph.Edges, graphMLEdge{
+			Source: edge.From,
+			Target: edge.To,
+			Data:   []graphMLData{{Key: "edgeKind", Value: edge.Kind}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+var dotShapes = map[string]string{
+	SecurityGroupNode:    "box",
+	NetworkInterfaceNode: "ellipse",
+	ResourceNode:         "component",
+	ConfigurationNode:    "note",
+}
+
+var mermaidShapes = map[string][2]string{
+	SecurityGroupNode:    {"[", "]"},
+	NetworkInterfaceNode: {"([", "])"},
+	ResourceNode:         {"[[", "]]"},
+	ConfigurationNode:    {"[/", "/]"},
+}
+
+var edgeLabels = map[string]string{
+	ReferencedByEdge: "referenced by",
+	UsedByEdge:       "used by",
+	OwnedByEdge:      "owned by",
+	ConfiguredByEdge: "configured by",
+}
+
+// Escape the characters which cannot appear in a quoted Mermaid label
+func escapeMermaid(label string) string {
+	return strings.NewReplacer("\"", "#quot;", "<", "#lt;", ">", "#gt;").Replace(label)
+}
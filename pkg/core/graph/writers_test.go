@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestGraph() *Graph {
+	eni := coreTypes.NetworkInterfaceDetails{
+		Id:               "eni-1",
+		AccountId:        "111111111111",
+		Region:           "eu-west-1",
+		PrivateIPAddress: "10.0.0.1",
+		LambdaAttachment: &coreTypes.LambdaAttachment{IsRemoved: true, Name: "orders"},
+	}
+	sg := newTestSecurityGroup("sg-a", "111111111111", []coreTypes.NetworkInterfaceDetails{eni})
+	sg.Name = `"web"`
+	return NewGraph([]coreTypes.SecurityGroupDetails{sg})
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteDOT(&b, newTestGraph()))
+	require.Equal(t, `digraph "sg-ripper" {
+  rankdir=LR;
+  "sg:111111111111:eu-west-1:sg-a" [label="\"web\" (sg-a)", shape=box];
+  "eni:111111111111:eu-west-1:eni-1" [label="eni-1 (10.0.0.1)", shape=ellipse];
+  "lambda:111111111111:eu-west-1:orders" [label="Lambda function orders (removed)", shape=component, style=dashed, color=orange];
+  "sg:111111111111:eu-west-1:sg-a" -> "eni:111111111111:eu-west-1:eni-1" [label="used by"];
+  "eni:111111111111:eu-west-1:eni-1" -> "lambda:111111111111:eu-west-1:orders" [label="owned by"];
+}
+`, b.String())
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteMermaid(&b, newTestGraph()))
+	require.Equal(t, `flowchart LR
+  n0["#quot;web#quot; (sg-a)"]
+  n1(["eni-1 (10.0.0.1)"])
+  n2[["Lambda function orders (removed)"]]
+  n0 -->|used by| n1
+  n1 -->|owned by| n2
+  classDef removed stroke:orange,stroke-dasharray:5 5
+  class n2 removed
+`, b.String())
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGraphML(&b, newTestGraph()))
+
+	var document graphML
+	require.NoError(t, xml.Unmarshal(b.Bytes(), &document))
+	require.Equal(t, "directed", document.Graph.EdgeDefault)
+
+	nodeIds := make([]string, 0)
+	for _, node := range document.Graph.Nodes {
+		nodeIds = append(nodeIds, node.Id)
+	}
+	require.Equal(t, []string{
+		"sg:111111111111:eu-west-1:sg-a",
+		"eni:111111111111:eu-west-1:eni-1",
+		"lambda:111111111111:eu-west-1:orders",
+	}, nodeIds)
+	require.Contains(t, document.Graph.Nodes[0].Data, graphMLData{Key: "label", Value: `"web" (sg-a)`})
+	require.Contains(t, document.Graph.Nodes[2].Data, graphMLData{Key: "isRemoved", Value: "true"})
+
+	require.Equal(t, []graphMLEdge{
+		{
+			Source: "sg:111111111111:eu-west-1:sg-a",
+			Target: "eni:111111111111:eu-west-1:eni-1",
+			Data:   []graphMLData{{Key: "edgeKind", Value: UsedByEdge}},
+		},
+		{
+			Source: "eni:111111111111:eu-west-1:eni-1",
+			Target: "lambda:111111111111:eu-west-1:orders",
+			Data:   []graphMLData{{Key: "edgeKind", Value: OwnedByEdge}},
+		},
+	}, document.Graph.Edges)
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	require.Error(t, Write(&b, newTestGraph(), "svg"))
+	require.Empty(t, b.String())
+}
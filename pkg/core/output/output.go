@@ -0,0 +1,153 @@
+package output
+
+import (
+	"encoding/json"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"io"
+)
+
+// SchemaVersion is the version of the machine-readable output. It is incremented only when a field is removed or
+// its meaning changes; adding new fields does not change the version.
+const SchemaVersion = 3
+
+// SecurityGroupsDocument is the top level JSON document produced by the list command
+type SecurityGroupsDocument struct {
+	SchemaVersion  int             `json:"schemaVersion"`
+	SecurityGroups []SecurityGroup `json:"securityGroups"`
+}
+
+// NetworkInterfacesDocument is the top level JSON document produced by the list-eni command
+type NetworkInterfacesDocument struct {
+	SchemaVersion     int                `json:"schemaVersion"`
+	NetworkInterfaces []NetworkInterface `json:"networkInterfaces"`
+}
+
+// AuditDocument is the top level JSON document produced by the audit command
+type AuditDocument struct {
+	SchemaVersion int                      `json:"schemaVersion"`
+	Findings      []coreTypes.AuditFinding `json:"findings"`
+}
+
+// DedupeDocument is the top level JSON document produced by the dedupe command
+type DedupeDocument struct {
+	SchemaVersion int `json:"schemaVersion"`
+	coreTypes.DedupeReport
+}
+
+// RuleFindingsDocument is the top level JSON document produced by the redundant-rules command
+type RuleFindingsDocument struct {
+	SchemaVersion int                     `json:"schemaVersion"`
+	Findings      []coreTypes.RuleFinding `json:"findings"`
+}
+
+// SecurityGroup extends coreTypes.SecurityGroupDetails with the computed usage information
+type SecurityGroup struct {
+	coreTypes.SecurityGroupDetails
+	UsedBy                []NetworkInterface `json:"usedBy"`
+	InUse                 bool               `json:"inUse"`
+	CanBeRemoved          bool               `json:"canBeRemoved"`
+	ReasonsAgainstRemoval []string           `json:"reasonsAgainstRemoval"`
+}
+
+// NetworkInterface extends coreTypes.NetworkInterfaceDetails with the computed usage information
+type NetworkInterface struct {
+	coreTypes.NetworkInterfaceDetails
+	InUse                 bool     `json:"inUse"`
+	CanBeRemoved          bool     `json:"canBeRemoved"`
+	ReasonsAgainstRemoval []string `json:"reasonsAgainstRemoval"`
+}
+
+// NewSecurityGroupsDocument creates a SecurityGroupsDocument from a slice of SecurityGroupDetails
+func NewSecurityGroupsDocument(groups []coreTypes.SecurityGroupDetails) SecurityGroupsDocument {
+	securityGroups := make([]SecurityGroup, 0, len(groups))
+	for _, sg := range groups {
+		securityGroups = append(securityGroups, newSecurityGroup(sg))
+	}
+	return SecurityGroupsDocument{
+		SchemaVersion:  SchemaVersion,
+		SecurityGroups: securityGroups,
+	}
+}
+
+// NewNetworkInterfacesDocument creates a NetworkInterfacesDocument from a slice of NetworkInterfaceDetails
+func NewNetworkInterfacesDocument(enis []coreTypes.NetworkInterfaceDetails) NetworkInterfacesDocument {
+	return NetworkInterfacesDocument{
+		SchemaVersion:     SchemaVersion,
+		NetworkInterfaces: newNetworkInterfaces(enis),
+	}
+}
+
+// NewAuditDocument creates an AuditDocument from a slice of AuditFinding
+func NewAuditDocument(findings []coreTypes.AuditFinding) AuditDocument {
+	if findings == nil {
+		findings = make([]coreTypes.AuditFinding, 0)
+	}
+	return AuditDocument{
+		SchemaVersion: SchemaVersion,
+		Findings:      findings,
+	}
+}
+
+// NewDedupeDocument creates a DedupeDocument from a DedupeReport
+func NewDedupeDocument(report coreTypes.DedupeReport) DedupeDocument {
+	return DedupeDocument{
+		SchemaVersion: SchemaVersion,
+		DedupeReport:  report,
+	}
+}
+
+// NewRuleFindingsDocument creates a RuleFindingsDocument from a slice of RuleFinding
+func NewRuleFindingsDocument(findings []coreTypes.RuleFinding) RuleFindingsDocument {
+	if findings == nil {
+		findings = make([]coreTypes.RuleFinding, 0)
+	}
+	return RuleFindingsDocument{
+		SchemaVersion: SchemaVersion,
+		Findings:      findings,
+	}
+}
+
+// WriteJSON writes the document as indented JSON to the writer
+func WriteJSON(w io.Writer, document any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+func newSecurityGroup(sg coreTypes.SecurityGroupDetails) SecurityGroup {
+	ruleReferences := sg.RuleReferences
+	if ruleReferences == nil {
+		ruleReferences = make([]string, 0)
+	}
+	sg.RuleReferences = ruleReferences
+	if sg.Rules == nil {
+		sg.Rules = make([]coreTypes.SecurityGroupRule, 0)
+	}
+
+	return SecurityGroup{
+		SecurityGroupDetails:  sg,
+		UsedBy:                newNetworkInterfaces(sg.UsedBy),
+		InUse:                 sg.IsInUse(),
+		CanBeRemoved:          sg.CanBeRemoved(),
+		ReasonsAgainstRemoval: sg.ReasonsAgainstRemoval(),
+	}
+}
+
+func newNetworkInterfaces(enis []coreTypes.NetworkInterfaceDetails) []NetworkInterface {
+	networkInterfaces := make([]NetworkInterface, 0, len(enis))
+	for _, eni := range enis {
+		if eni.SecondaryPrivateIPAddresses == nil {
+			eni.SecondaryPrivateIPAddresses = make([]string, 0)
+		}
+		if eni.SecurityGroupIdentifiers == nil {
+			eni.SecurityGroupIdentifiers = make([]coreTypes.SecurityGroupIdentifier, 0)
+		}
+		networkInterfaces = append(networkInterfaces, NetworkInterface{
+			NetworkInterfaceDetails: eni,
+			InUse:                   eni.IsInUse(),
+			CanBeRemoved:            eni.CanBeRemoved(),
+			ReasonsAgainstRemoval:   eni.ReasonsAgainstRemoval(),
+		})
+	}
+	return networkInterfaces
+}
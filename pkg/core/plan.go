@@ -0,0 +1,423 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlanVersion is the version of the plan file format
+const PlanVersion = 3
+
+type PlanKind string
+
+const (
+	SecurityGroupsPlan    PlanKind = "security-groups"
+	NetworkInterfacesPlan PlanKind = "network-interfaces"
+)
+
+// RemovalPlan contains the resources which were found to be removable at the time of planning, together with a
+// fingerprint of their state. Applying the plan removes only the resources which state has not changed since.
+type RemovalPlan struct {
+	Version   int           `json:"version"`
+	Kind      PlanKind      `json:"kind"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Items     []PlanItem    `json:"items"`
+	Skipped   []SkippedItem `json:"skipped"`
+}
+
+// PlanItem is a resource which will be removed when the plan is applied
+type PlanItem struct {
+	Id          string `json:"id"`
+	AccountId   string `json:"accountId"`
+	Region      string `json:"region"`
+	Name        string `json:"name,omitempty"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// SkippedItem is a resource which was explicitly requested for removal, but it cannot be removed. The account and the
+// region are empty if the resource was not found in any account and region
+type SkippedItem struct {
+	Id        string   `json:"id"`
+	AccountId string   `json:"accountId,omitempty"`
+	Region    string   `json:"region,omitempty"`
+	Reasons   []string `json:"reasons"`
+}
+
+// PlanSecurityGroupsRemoval creates a RemovalPlan with the Security Groups which can be removed from every account and
+// region of the scope. If the slice with the IDs is empty, every Security Group matching the filters will be evaluated,
+// otherwise the Security Groups which are not found or do not match the filters are skipped, the same way as they are
+// refused by RemoveSecurityGroupsAsync. The accounts and regions which cannot be scanned are reported by a ScanError,
+// returned together with the plan.
+func PlanSecurityGroupsRemoval(ctx context.Context, securityGroupIds []string, filters Filters,
+	scope Scope) (*RemovalPlan, error) {
+	groups, _, scanErr := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.SecurityGroupDetails, error) {
+		return listSecurityGroups(ctx, t, securityGroupIds, scope.Protection)
+	})
+	if _, err := asScanError(scanErr); err != nil {
+		return nil, err
+	}
+
+	plan := newRemovalPlan(SecurityGroupsPlan)
+	for _, id := range missingIds(securityGroupIds, securityGroupIdsOf(groups)) {
+		plan.Skipped = append(plan.Skipped, SkippedItem{Id: id, Reasons: []string{"Security Group not found"}})
+	}
+	for _, sg := range groups {
+		switch {
+		case !filters.matchesSecurityGroup(sg):
+			if len(securityGroupIds) > 0 {
+				plan.Skipped = append(plan.Skipped, SkippedItem{
+					Id:        sg.Id,
+					AccountId: sg.AccountId,
+					Region:    sg.Region,
+					Reasons:   []string{"Security Group does not match the filters"},
+				})
+			}
+		case sg.CanBeRemoved():
+			plan.Items = append(plan.Items, PlanItem{
+				Id:          sg.Id,
+				AccountId:   sg.AccountId,
+				Region:      sg.Region,
+				Name:        sg.Name,
+				Fingerprint: securityGroupFingerprint(sg),
+			})
+		case len(securityGroupIds) > 0:
+			plan.Skipped = append(plan.Skipped, SkippedItem{
+				Id:        sg.Id,
+				AccountId: sg.AccountId,
+				Region:    sg.Region,
+				Reasons:   sg.ReasonsAgainstRemoval(),
+			})
+		}
+	}
+
+	return plan, scanErr
+}
+
+// PlanNetworkInterfacesRemoval creates a RemovalPlan with the Network Interfaces which can be removed from every
+// account and region of the scope. If the slice with the IDs is empty, every Network Interface matching the filters
+// will be evaluated, otherwise the Network Interfaces which are not found or do not match the filters are skipped, the
+// same way as they are refused by RemoveENIAsync. The accounts and regions which cannot be scanned are reported by a
+// ScanError, returned together with the plan.
+func PlanNetworkInterfacesRemoval(ctx context.Context, eniIds []string, filters Filters,
+	scope Scope) (*RemovalPlan, error) {
+	enis, _, scanErr := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.NetworkInterfaceDetails, error) {
+		return listNetworkInterfaces(ctx, t, eniIds, scope.Protection)
+	})
+	if _, err := asScanError(scanErr); err != nil {
+		return nil, err
+	}
+
+	plan := newRemovalPlan(NetworkInterfacesPlan)
+	for _, id := range missingIds(eniIds, networkInterfaceIdsOf(enis)) {
+		plan.Skipped = append(plan.Skipped, SkippedItem{Id: id, Reasons: []string{"Network Interface not found"}})
+	}
+	for _, eni := range enis {
+		switch {
+		case !filters.matchesNetworkInterface(eni):
+			if len(eniIds) > 0 {
+				plan.Skipped = append(plan.Skipped, SkippedItem{
+					Id:        eni.Id,
+					AccountId: eni.AccountId,
+					Region:    eni.Region,
+					Reasons:   []string{"Network Interface does not match the filters"},
+				})
+			}
+		case eni.CanBeRemoved():
+			plan.Items = append(plan.Items, PlanItem{
+				Id:          eni.Id,
+				AccountId:   eni.AccountId,
+				Region:      eni.Region,
+				Fingerprint: networkInterfaceFingerprint(eni),
+			})
+		case len(eniIds) > 0:
+			plan.Skipped = append(plan.Skipped, SkippedItem{
+				Id:        eni.Id,
+				AccountId: eni.AccountId,
+				Region:    eni.Region,
+				Reasons:   eni.ReasonsAgainstRemoval(),
+			})
+		}
+	}
+
+	return plan, scanErr
+}
+
+// ApplyRemovalPlanAsync re-validates every item of the plan against the live state and removes the ones which did not
+// change since planning. Items which changed are reported as errors on the result channel. Every item is removed from
+// the account and the region in which it was planned, only the profile, the role name and the protection of the scope
+// being used. The Force option has no effect, Security Groups are backed up if a backup directory is provided.
+func ApplyRemovalPlanAsync(ctx context.Context, plan *RemovalPlan, options RemoveOptions, scope Scope,
+	resultCh chan utils.Result[coreTypes.ResourceRef]) error {
+	if len(plan.Items) == 0 {
+		close(resultCh)
+		return nil
+	}
+
+	planScope := Scope{Profile: scope.Profile, RoleName: scope.RoleName, Protection: scope.Protection}
+	idsByLocation := make(map[location][]string)
+	for _, item := range plan.Items {
+		itemLocation := location{accountId: item.AccountId, region: item.Region}
+		if !slices.Contains(planScope.Accounts, item.AccountId) {
+			planScope.Accounts = append(planScope.Accounts, item.AccountId)
+		}
+		if !slices.Contains(planScope.Regions, item.Region) {
+			planScope.Regions = append(planScope.Regions, item.Region)
+		}
+		idsByLocation[itemLocation] = append(idsByLocation[itemLocation], item.Id)
+	}
+
+	allTargets, err := resolveTargets(ctx, planScope)
+	if _, err := asScanError(err); err != nil {
+		return err
+	}
+
+	// Every combination of the planned accounts and regions is resolved, only the ones with planned items are needed
+	targets := make([]target, 0, len(idsByLocation))
+	for _, t := range allTargets {
+		if len(idsByLocation[t.location()]) > 0 {
+			targets = append(targets, t)
+		}
+	}
+
+	var states []resourceState
+	switch plan.Kind {
+	case SecurityGroupsPlan:
+		states, err = forEachTarget(ctx, targets, func(ctx context.Context, t target) ([]resourceState, error) {
+			return currentSecurityGroupsState(ctx, t, idsByLocation[t.location()], scope.Protection)
+		})
+	case NetworkInterfacesPlan:
+		states, err = forEachTarget(ctx, targets, func(ctx context.Context, t target) ([]resourceState, error) {
+			return currentNetworkInterfacesState(ctx, t, idsByLocation[t.location()], scope.Protection)
+		})
+	default:
+		err = fmt.Errorf("unsupported plan kind %q", plan.Kind)
+	}
+	scanErr, err := asScanError(err)
+	if err != nil {
+		return err
+	}
+
+	// The items of the accounts and regions which cannot be scanned are rejected with the error of their target
+	targetErrs := make(map[location]error)
+	if scanErr != nil {
+		for _, targetErr := range scanErr.Errors {
+			targetErrs[location{accountId: targetErr.AccountId, region: targetErr.Region}] = targetErr
+		}
+	}
+
+	statesByRef := make(map[coreTypes.ResourceRef]resourceState, len(states))
+	for _, state := range states {
+		statesByRef[state.ref] = state
+	}
+
+	unchanged := make(map[location][]string)
+	rejected := make([]error, 0)
+	for _, item := range plan.Items {
+		ref := coreTypes.ResourceRef{Id: item.Id, AccountId: item.AccountId, Region: item.Region}
+		state, ok := statesByRef[ref]
+		targetErr := targetErrs[location{accountId: item.AccountId, region: item.Region}]
+		switch {
+		case targetErr != nil:
+			rejected = append(rejected, fmt.Errorf("skipping %s: %w", ref, targetErr))
+		case !ok:
+			rejected = append(rejected, fmt.Errorf("skipping %s: the resource no longer exists", ref))
+		case len(state.reasons) > 0:
+			rejected = append(rejected, fmt.Errorf("skipping %s: the resource cannot be removed anymore: %s",
+				ref, strings.Join(state.reasons, "; ")))
+		case state.fingerprint != item.Fingerprint:
+			rejected = append(rejected, fmt.Errorf("skipping %s: the state of the resource changed since planning",
+				ref))
+		default:
+			itemLocation := location{accountId: item.AccountId, region: item.Region}
+			unchanged[itemLocation] = append(unchanged[itemLocation], item.Id)
+		}
+	}
+
+	if plan.Kind == SecurityGroupsPlan {
+		return removeSecurityGroups(ctx, targets, unchanged, options, rejected, resultCh)
+	}
+	removeNetworkInterfaces(ctx, targets, unchanged, rejected, resultCh)
+	return nil
+}
+
+// WriteRemovalPlan saves the plan as a JSON file
+func WriteRemovalPlan(path string, plan *RemovalPlan) error {
+	content, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// ReadRemovalPlan loads a plan from a JSON file and validates its version and kind
+func ReadRemovalPlan(path string, kind PlanKind) (*RemovalPlan, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan RemovalPlan
+	if err := json.Unmarshal(content, &plan); err != nil {
+		return nil, fmt.Errorf("invalid plan file %s: %w", path, err)
+	}
+
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", plan.Version, PlanVersion)
+	}
+	if plan.Kind != kind {
+		return nil, fmt.Errorf("the plan file contains %s, expected %s", plan.Kind, kind)
+	}
+
+	return &plan, nil
+}
+
+func newRemovalPlan(kind PlanKind) *RemovalPlan {
+	return &RemovalPlan{
+		Version:   PlanVersion,
+		Kind:      kind,
+		CreatedAt: time.Now().UTC(),
+		Items:     make([]PlanItem, 0),
+		Skipped:   make([]SkippedItem, 0),
+	}
+}
+
+// The current state of a planned resource
+type resourceState struct {
+	ref         coreTypes.ResourceRef
+	fingerprint string
+	reasons     []string
+}
+
+// Get the fingerprints and the reasons against removal for the Security Groups provided
+func currentSecurityGroupsState(ctx context.Context, t target, ids []string,
+	protection Protection) ([]resourceState, error) {
+	groups, err := listSecurityGroups(ctx, t, ids, protection)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]resourceState, 0, len(groups))
+	for _, sg := range groups {
+		states = append(states, resourceState{
+			ref:         coreTypes.ResourceRef{Id: sg.Id, AccountId: sg.AccountId, Region: sg.Region},
+			fingerprint: securityGroupFingerprint(sg),
+			reasons:     sg.ReasonsAgainstRemoval(),
+		})
+	}
+	return states, nil
+}
+
+// Get the fingerprints and the reasons against removal for the Network Interfaces provided
+func currentNetworkInterfacesState(ctx context.Context, t target, ids []string,
+	protection Protection) ([]resourceState, error) {
+	enis, err := listNetworkInterfaces(ctx, t, ids, protection)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]resourceState, 0, len(enis))
+	for _, eni := range enis {
+		states = append(states, resourceState{
+			ref:         coreTypes.ResourceRef{Id: eni.Id, AccountId: eni.AccountId, Region: eni.Region},
+			fingerprint: networkInterfaceFingerprint(eni),
+			reasons:     eni.ReasonsAgainstRemoval(),
+		})
+	}
+	return states, nil
+}
+
+// Compute a fingerprint from the properties of a Security Group which are relevant for its removal, including the
+// rules and the tags which would be lost, and the configurations which would fail to launch without it
+func securityGroupFingerprint(sg coreTypes.SecurityGroupDetails) string {
+	usedBy := make([]string, 0, len(sg.UsedBy))
+	for _, eni := range sg.UsedBy {
+		usedBy = append(usedBy, eni.Id)
+	}
+
+	rules := make([]coreTypes.SecurityGroupRule, len(sg.Rules))
+	copy(rules, sg.Rules)
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Id < rules[j].Id
+	})
+
+	configurationReferences := make([]string, 0, len(sg.ConfigurationReferences))
+	for _, reference := range sg.ConfigurationReferences {
+		configurationReferences = append(configurationReferences, fmt.Sprintf("%s|%s|%s|%s|%s", reference.Type,
+			reference.Name, reference.Id, reference.Version, reference.Alias))
+	}
+
+	return fingerprint(struct {
+		Name                    string
+		Description             string
+		VpcId                   string
+		Default                 bool
+		UsedBy                  []string
+		RuleReferences          []string
+		Rules                   []coreTypes.SecurityGroupRule
+		Tags                    map[string]string
+		ConfigurationReferences []string
+	}{
+		Name:                    sg.Name,
+		Description:             sg.Description,
+		VpcId:                   sg.VpcId,
+		Default:                 sg.Default,
+		UsedBy:                  sortedCopy(usedBy),
+		RuleReferences:          sortedCopy(sg.RuleReferences),
+		Rules:                   rules,
+		Tags:                    sg.Tags,
+		ConfigurationReferences: sortedCopy(configurationReferences),
+	})
+}
+
+// Compute a fingerprint from the properties of a Network Interface which are relevant for its removal
+func networkInterfaceFingerprint(eni coreTypes.NetworkInterfaceDetails) string {
+	securityGroups := make([]string, 0, len(eni.SecurityGroupIdentifiers))
+	for _, identifier := range eni.SecurityGroupIdentifiers {
+		securityGroups = append(securityGroups, identifier.Id)
+	}
+
+	var instanceId string
+	if eni.EC2Attachment != nil {
+		instanceId = eni.EC2Attachment.InstanceId
+	}
+
+	return fingerprint(struct {
+		Description      *string
+		Type             string
+		Status           string
+		PrivateIPAddress string
+		InstanceId       string
+		SecurityGroups   []string
+	}{
+		Description:      eni.Description,
+		Type:             eni.Type,
+		Status:           eni.Status,
+		PrivateIPAddress: eni.PrivateIPAddress,
+		InstanceId:       instanceId,
+		SecurityGroups:   sortedCopy(securityGroups),
+	})
+}
+
+func fingerprint(state any) string {
+	// Marshalling a struct can not fail, the field order is stable and the keys of maps are sorted
+	content, _ := json.Marshal(state)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func sortedCopy(values []string) []string {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSecurityGroupFingerprint(t *testing.T) {
+	newGroup := func() coreTypes.SecurityGroupDetails {
+		sg := *coreTypes.NewSecurityGroup("web", "sg-a", "Web servers",
+			[]coreTypes.NetworkInterfaceDetails{{Id: "eni-1"}, {Id: "eni-2"}}, []string{"sg-b", "sg-c"}, "vpc-1")
+		sg.Rules = []coreTypes.SecurityGroupRule{
+			{Id: "sgr-1", GroupId: "sg-a", Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIpv4: aws.String("10.0.0.0/8")},
+			{Id: "sgr-2", GroupId: "sg-a", IsEgress: true, Protocol: "-1", CidrIpv4: aws.String("0.0.0.0/0")},
+		}
+		return sg
+	}
+
+	tests := []struct {
+		name    string
+		change  func(sg *coreTypes.SecurityGroupDetails)
+		changed bool
+	}{
+		{
+			name:   "same state",
+			change: func(sg *coreTypes.SecurityGroupDetails) {},
+		},
+		{
+			name: "different order of the Network Interfaces, the rule references and the rules",
+			change: func(sg *coreTypes.SecurityGroupDetails) {
+				sg.UsedBy = []coreTypes.NetworkInterfaceDetails{{Id: "eni-2"}, {Id: "eni-1"}}
+				sg.RuleReferences = []string{"sg-c", "sg-b"}
+				sg.Rules = []coreTypes.SecurityGroupRule{sg.Rules[1], sg.Rules[0]}
+			},
+		},
+		{
+			name:    "description",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.Description = "Internal web servers" },
+			changed: true,
+		},
+		{
+			name:    "Network Interface",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.UsedBy = sg.UsedBy[:1] },
+			changed: true,
+		},
+		{
+			name:    "rule reference",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.RuleReferences = append(sg.RuleReferences, "sg-d") },
+			changed: true,
+		},
+		{
+			name:    "rule",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.Rules[0].ToPort = 8443 },
+			changed: true,
+		},
+		{
+			name:    "removed rule",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.Rules = sg.Rules[:1] },
+			changed: true,
+		},
+		{
+			name:    "tags",
+			change:  func(sg *coreTypes.SecurityGroupDetails) { sg.Tags = map[string]string{"team": "web"} },
+			changed: true,
+		},
+		{
+			name: "configuration reference",
+			change: func(sg *coreTypes.SecurityGroupDetails) {
+				sg.ConfigurationReferences = []coreTypes.ConfigurationReference{
+					{Type: coreTypes.AutoScalingGroupReference, Name: "web"},
+				}
+			},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := newGroup()
+			tt.change(&sg)
+			require.Equal(t, tt.changed, securityGroupFingerprint(newGroup()) != securityGroupFingerprint(sg))
+		})
+	}
+}
+
+func TestMissingIds(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		found     []string
+		missing   []string
+	}{
+		{name: "nothing requested", found: []string{"sg-a"}, missing: []string{}},
+		{name: "every ID found", requested: []string{"sg-a", "sg-b"}, found: []string{"sg-b", "sg-a"}, missing: []string{}},
+		{
+			name:      "missing IDs",
+			requested: []string{"sg-a", "sg-b", "sg-c"},
+			found:     []string{"sg-b"},
+			missing:   []string{"sg-a", "sg-c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.missing, missingIds(tt.requested, tt.found))
+		})
+	}
+}
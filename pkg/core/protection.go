@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultProtectionTag is the tag protecting Security Groups and Network Interfaces from removal if no other tag is
+// specified
+const DefaultProtectionTag = "sg-ripper:protect=true"
+
+// Protection describes the Security Groups and Network Interfaces which must never be removed. Protected resources are
+// reported as not removable and they are refused even if the removal is forced
+type Protection struct {
+	// Tag protects every resource matching the tag selector. If it is nil, no resource is protected by its tags
+	Tag *TagSelector
+	// KeepList protects the resources with one of the IDs
+	KeepList []string
+}
+
+// DefaultProtection returns the protection by the DefaultProtectionTag, which is used if no other protection is
+// specified
+func DefaultProtection() Protection {
+	// The default tag is a valid selector
+	selector, _ := ParseTagSelector(DefaultProtectionTag)
+	return Protection{Tag: &selector}
+}
+
+// DefaultKeepListFile returns the keep-list file used if no other file is specified
+func DefaultKeepListFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sg-ripper", "keep-list"), nil
+}
+
+// ReadKeepList loads the IDs of the protected resources from a keep-list file. The file contains one ID per line,
+// everything after a "#" is treated as a comment and empty lines are ignored
+func ReadKeepList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ids := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if id := strings.TrimSpace(line); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid keep-list file %s: %w", path, err)
+	}
+
+	return ids, nil
+}
+
+// Return what protects the resource with the ID and the tags provided, or an empty string if it is not protected
+func (p Protection) protectedBy(id string, tags map[string]string) string {
+	if slices.Contains(p.KeepList, id) {
+		return "the keep-list"
+	}
+	if p.Tag != nil && p.Tag.Matches(tags) {
+		return fmt.Sprintf("the tag %s", p.Tag)
+	}
+	return ""
+}
+
+// Mark the protected Security Groups
+func (p Protection) protectSecurityGroups(groups []coreTypes.SecurityGroupDetails) {
+	for i := range groups {
+		groups[i].ProtectedBy = p.protectedBy(groups[i].Id, groups[i].Tags)
+	}
+}
+
+// Mark the protected Network Interfaces
+func (p Protection) protectNetworkInterfaces(enis []coreTypes.NetworkInterfaceDetails) {
+	for i := range enis {
+		enis[i].ProtectedBy = p.protectedBy(enis[i].Id, enis[i].Tags)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadKeepList(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		ids     []string
+	}{
+		{
+			name:    "one ID per line",
+			content: "sg-0123456789abcdef0\neni-0123456789abcdef0\n",
+			ids:     []string{"sg-0123456789abcdef0", "eni-0123456789abcdef0"},
+		},
+		{
+			name:    "comments and empty lines",
+			content: "# protected resources\n\nsg-a # referenced by the pipeline\n   \n  eni-b  \n#sg-c\n",
+			ids:     []string{"sg-a", "eni-b"},
+		},
+		{
+			name:    "Windows line endings and no trailing newline",
+			content: "sg-a\r\nsg-b",
+			ids:     []string{"sg-a", "sg-b"},
+		},
+		{
+			name:    "empty file",
+			content: "",
+			ids:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keep-list")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			ids, err := ReadKeepList(path)
+			require.NoError(t, err)
+			require.Equal(t, tt.ids, ids)
+		})
+	}
+}
+
+func TestReadKeepListMissingFile(t *testing.T) {
+	_, err := ReadKeepList(filepath.Join(t.TempDir(), "keep-list"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestProtectedBy(t *testing.T) {
+	tests := []struct {
+		name        string
+		protection  Protection
+		id          string
+		tags        map[string]string
+		protectedBy string
+	}{
+		{
+			name:        "default protection tag",
+			protection:  DefaultProtection(),
+			id:          "sg-a",
+			tags:        map[string]string{"sg-ripper:protect": "true"},
+			protectedBy: "the tag sg-ripper:protect=true",
+		},
+		{
+			name:       "default protection tag with another value",
+			protection: DefaultProtection(),
+			id:         "sg-a",
+			tags:       map[string]string{"sg-ripper:protect": "false"},
+		},
+		{
+			name:        "keep-list",
+			protection:  Protection{KeepList: []string{"sg-b", "sg-a"}},
+			id:          "sg-a",
+			protectedBy: "the keep-list",
+		},
+		{
+			name:       "not in the keep-list",
+			protection: Protection{KeepList: []string{"sg-b"}},
+			id:         "sg-a",
+		},
+		{
+			name:        "keep-list is reported before the tag",
+			protection:  Protection{Tag: DefaultProtection().Tag, KeepList: []string{"sg-a"}},
+			id:          "sg-a",
+			tags:        map[string]string{"sg-ripper:protect": "true"},
+			protectedBy: "the keep-list",
+		},
+		{
+			name:       "protection by tag disabled",
+			protection: Protection{},
+			id:         "sg-a",
+			tags:       map[string]string{"sg-ripper:protect": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.protectedBy, tt.protection.protectedBy(tt.id, tt.tags))
+		})
+	}
+}
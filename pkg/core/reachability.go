@@ -0,0 +1,163 @@
+package core
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"slices"
+	"sort"
+)
+
+// Find the Security Groups which are referenced only by rules of other unused Security Groups, including the ones
+// referencing each other in a cycle, and record for each of them the set of Security Groups which can be removed
+// together with it, in the order of their removal.
+//
+// A Security Group is alive if it is in use by anything other than a rule, if it is a default or a protected Security
+// Group, or if it is referenced by a rule of an alive Security Group. Security Groups which are not part of the groups
+// provided, for example because only some IDs were listed, are considered alive.
+func markRemovalSets(groups []coreTypes.SecurityGroupDetails) {
+	indexes := make(map[string]int, len(groups))
+	for i, sg := range groups {
+		indexes[sg.Id] = i
+	}
+
+	// For each Security Group, the Security Groups referenced by its rules
+	referenced := make(map[string][]string)
+	alive := make(map[string]bool)
+	queue := make([]string, 0)
+	for _, sg := range groups {
+		isAlive := sg.Default || sg.IsProtected() || len(sg.UsedBy) > 0 || len(sg.ConfigurationReferences) > 0 ||
+			len(sg.UncheckedConfigurations) > 0
+		for _, referencingId := range sg.RuleReferences {
+			if referencingId == sg.Id {
+				continue
+			}
+			if _, ok := indexes[referencingId]; !ok {
+				isAlive = true
+				continue
+			}
+			if !slices.Contains(referenced[referencingId], sg.Id) {
+				referenced[referencingId] = append(referenced[referencingId], sg.Id)
+			}
+		}
+		if isAlive {
+			alive[sg.Id] = true
+			queue = append(queue, sg.Id)
+		}
+	}
+
+	for len(queue) > 0 {
+		sgId := queue[0]
+		queue = queue[1:]
+		for _, referencedId := range referenced[sgId] {
+			if !alive[referencedId] {
+				alive[referencedId] = true
+				queue = append(queue, referencedId)
+			}
+		}
+	}
+
+	for i := range groups {
+		sg := &groups[i]
+		if alive[sg.Id] || len(sg.RuleReferences) == 0 {
+			continue
+		}
+		sg.RemovalSet, sg.RemovalSetHasCycles = getRemovalOrder(getReferencingClosure(sg.Id, groups, indexes))
+	}
+}
+
+// Get a Security Group together with every Security Group referencing it, directly or transitively
+func getReferencingClosure(sgId string, groups []coreTypes.SecurityGroupDetails, indexes map[string]int) map[string][]string {
+	closure := make(map[string][]string)
+	queue := []string{sgId}
+	for len(queue) > 0 {
+		currentId := queue[0]
+		queue = queue[1:]
+		if _, ok := closure[currentId]; ok {
+			continue
+		}
+
+		referencingIds := make([]string, 0)
+		for _, referencingId := range groups[indexes[currentId]].RuleReferences {
+			if referencingId != currentId && !slices.Contains(referencingIds, referencingId) {
+				referencingIds = append(referencingIds, referencingId)
+				queue = append(queue, referencingId)
+			}
+		}
+		closure[currentId] = referencingIds
+	}
+	return closure
+}
+
+// Order the Security Groups so that every Security Group is removed after the Security Groups referencing it. The
+// Security Groups referencing each other in a cycle are placed next to each other and the returned flag is true, since
+// the rules referencing them have to be revoked before they can be removed
+func getRemovalOrder(closure map[string][]string) ([]string, bool) {
+	order := make([]string, 0, len(closure))
+	hasCycles := false
+	for _, component := range getRemovalComponents(closure) {
+		order = append(order, component...)
+		if len(component) > 1 {
+			hasCycles = true
+		}
+	}
+	return order, hasCycles
+}
+
+// Group the Security Groups referencing each other in a cycle into components, and order the components so that every
+// component comes after the components referencing it. The components are the strongly connected components of the
+// graph, found with Tarjan's algorithm, which emits every component after the components referencing it
+func getRemovalComponents(closure map[string][]string) [][]string {
+	sgIds := make([]string, 0, len(closure))
+	for sgId := range closure {
+		sgIds = append(sgIds, sgId)
+	}
+	sort.Strings(sgIds)
+
+	index := 0
+	indexes := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	components := make([][]string, 0)
+
+	var connect func(sgId string)
+	connect = func(sgId string) {
+		indexes[sgId] = index
+		lowLinks[sgId] = index
+		index++
+		stack = append(stack, sgId)
+		onStack[sgId] = true
+
+		referencingIds := slices.Clone(closure[sgId])
+		sort.Strings(referencingIds)
+		for _, referencingId := range referencingIds {
+			if _, ok := indexes[referencingId]; !ok {
+				connect(referencingId)
+				lowLinks[sgId] = min(lowLinks[sgId], lowLinks[referencingId])
+			} else if onStack[referencingId] {
+				lowLinks[sgId] = min(lowLinks[sgId], indexes[referencingId])
+			}
+		}
+
+		if lowLinks[sgId] == indexes[sgId] {
+			component := make([]string, 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == sgId {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, sgId := range sgIds {
+		if _, ok := indexes[sgId]; !ok {
+			connect(sgId)
+		}
+	}
+	return components
+}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTestGroup(id string, ruleReferences ...string) coreTypes.SecurityGroupDetails {
+	return *coreTypes.NewSecurityGroup(id, id, "", nil, ruleReferences, "vpc-1")
+}
+
+func TestMarkRemovalSets(t *testing.T) {
+	inUse := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.UsedBy = []coreTypes.NetworkInterfaceDetails{{Id: "eni-1"}}
+		return sg
+	}
+	protected := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.ProtectedBy = "the keep-list"
+		return sg
+	}
+	unchecked := func(sg coreTypes.SecurityGroupDetails) coreTypes.SecurityGroupDetails {
+		sg.UncheckedConfigurations = []string{"Lambda functions: access denied"}
+		return sg
+	}
+
+	tests := []struct {
+		name      string
+		groups    []coreTypes.SecurityGroupDetails
+		sets      map[string][]string
+		hasCycles map[string]bool
+	}{
+		{
+			name:   "referenced by an unused group",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), newTestGroup("sg-b")},
+			sets:   map[string][]string{"sg-a": {"sg-b", "sg-a"}},
+		},
+		{
+			name: "chain is removed from the last referencing group",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), newTestGroup("sg-b", "sg-c"),
+				newTestGroup("sg-c")},
+			sets: map[string][]string{
+				"sg-a": {"sg-c", "sg-b", "sg-a"},
+				"sg-b": {"sg-c", "sg-b"},
+			},
+		},
+		{
+			name:   "groups referencing each other",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), newTestGroup("sg-b", "sg-a")},
+			sets: map[string][]string{
+				"sg-a": {"sg-a", "sg-b"},
+				"sg-b": {"sg-a", "sg-b"},
+			},
+			hasCycles: map[string]bool{"sg-a": true, "sg-b": true},
+		},
+		{
+			name: "cycle referenced by another group is removed after it",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), newTestGroup("sg-b", "sg-c"),
+				newTestGroup("sg-c", "sg-b")},
+			sets: map[string][]string{
+				"sg-a": {"sg-b", "sg-c", "sg-a"},
+				"sg-b": {"sg-b", "sg-c"},
+				"sg-c": {"sg-b", "sg-c"},
+			},
+			hasCycles: map[string]bool{"sg-a": true, "sg-b": true, "sg-c": true},
+		},
+		{
+			name:   "self-reference only",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-a")},
+			sets:   map[string][]string{"sg-a": {"sg-a"}},
+		},
+		{
+			name:   "referenced by a group in use",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), inUse(newTestGroup("sg-b"))},
+		},
+		{
+			name: "referenced transitively by a protected group",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), newTestGroup("sg-b", "sg-c"),
+				protected(newTestGroup("sg-c"))},
+		},
+		{
+			name:   "referenced by a group with unchecked configurations",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"), unchecked(newTestGroup("sg-b"))},
+		},
+		{
+			name:   "referenced by a group which is not listed",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-x")},
+		},
+		{
+			name: "cycle kept alive by one of its groups",
+			groups: []coreTypes.SecurityGroupDetails{newTestGroup("sg-a", "sg-b"),
+				inUse(newTestGroup("sg-b", "sg-a"))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markRemovalSets(tt.groups)
+
+			for _, sg := range tt.groups {
+				require.Equal(t, tt.sets[sg.Id], sg.RemovalSet, sg.Id)
+				require.Equal(t, tt.hasCycles[sg.Id], sg.RemovalSetHasCycles, sg.Id)
+			}
+		})
+	}
+}
+
+func TestGetRemovalOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		closure   map[string][]string
+		order     []string
+		hasCycles bool
+	}{
+		{
+			name:    "single group",
+			closure: map[string][]string{"sg-a": {}},
+			order:   []string{"sg-a"},
+		},
+		{
+			name:    "referencing groups come first",
+			closure: map[string][]string{"sg-a": {"sg-b", "sg-c"}, "sg-b": {"sg-c"}, "sg-c": {}},
+			order:   []string{"sg-c", "sg-b", "sg-a"},
+		},
+		{
+			name:      "cycle is kept together and sorted",
+			closure:   map[string][]string{"sg-a": {"sg-c"}, "sg-c": {"sg-b"}, "sg-b": {"sg-a"}},
+			order:     []string{"sg-a", "sg-b", "sg-c"},
+			hasCycles: true,
+		},
+		{
+			name: "cycle between a referenced and a referencing group",
+			closure: map[string][]string{
+				"sg-a": {"sg-b"},
+				"sg-b": {"sg-c"},
+				"sg-c": {"sg-b", "sg-d"},
+				"sg-d": {},
+			},
+			order:     []string{"sg-d", "sg-b", "sg-c", "sg-a"},
+			hasCycles: true,
+		},
+		{
+			name: "cycle referenced by another cycle",
+			closure: map[string][]string{
+				"sg-a": {"sg-b", "sg-c"},
+				"sg-b": {"sg-a"},
+				"sg-c": {"sg-d"},
+				"sg-d": {"sg-c"},
+			},
+			order:     []string{"sg-c", "sg-d", "sg-a", "sg-b"},
+			hasCycles: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, hasCycles := getRemovalOrder(tt.closure)
+			require.Equal(t, tt.order, order)
+			require.Equal(t, tt.hasCycles, hasCycles)
+		})
+	}
+}
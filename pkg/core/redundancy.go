@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/analysis"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"slices"
+)
+
+// The redundant rules found in a single target, together with the IDs of the Security Groups analyzed
+type redundantRules struct {
+	securityGroupIds []string
+	findings         []coreTypes.RuleFinding
+}
+
+// ListRedundantRulesInScope returns the redundant rules of the Security Groups from every account and region of the
+// scope based on the input Security Group ID list and filters, together with the rules referencing Security Groups
+// which do not exist anymore. Rules referencing prefix lists are compared using the entries of the prefix lists. If
+// the slice with the IDs is empty, the rules of every Security Group are analyzed. The accounts and regions which
+// cannot be scanned are reported by a ScanError, returned together with the findings of the other ones
+func ListRedundantRulesInScope(ctx context.Context, securityGroupIds []string, filters Filters,
+	scope Scope) ([]coreTypes.RuleFinding, error) {
+	results, _, scanErr := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]redundantRules, error) {
+		groups, err := listSecurityGroups(ctx, t, securityGroupIds, scope.Protection)
+		if err != nil {
+			return nil, err
+		}
+
+		filtered := applyFilters(groups, filters)
+		ruleContext, err := getRuleContext(ctx, t, filtered, securityGroupIdsOf(groups))
+		if err != nil {
+			return nil, err
+		}
+		return []redundantRules{{
+			securityGroupIds: securityGroupIdsOf(groups),
+			findings:         analysis.FindRedundantRules(filtered, ruleContext),
+		}}, nil
+	})
+	if _, err := asScanError(scanErr); err != nil {
+		return nil, err
+	}
+
+	foundIds := make([]string, 0)
+	findings := make([]coreTypes.RuleFinding, 0)
+	for _, result := range results {
+		foundIds = append(foundIds, result.securityGroupIds...)
+		findings = append(findings, result.findings...)
+	}
+
+	if missing := missingIds(securityGroupIds, foundIds); len(missing) > 0 {
+		return nil, notFoundError("security group(s)", missing, scanErr)
+	}
+
+	return findings, scanErr
+}
+
+// Resolve the entries of the prefix lists referenced by the rules of the Security Groups, and find the referenced
+// Security Groups of the account which do not exist anymore. The Security Groups with the known IDs were already found,
+// the ones of other accounts cannot be checked
+func getRuleContext(ctx context.Context, t target, groups []coreTypes.SecurityGroupDetails,
+	knownIds []string) (analysis.RuleContext, error) {
+	ruleContext := analysis.RuleContext{
+		PrefixLists:           make(map[string][]string),
+		MissingSecurityGroups: make(map[string]bool),
+	}
+	ec2Client := clients.NewAwsEc2Client(t.cfg)
+
+	referencedIds := make([]string, 0)
+	for _, sg := range groups {
+		for _, rule := range sg.Rules {
+			if rule.PrefixListId != nil {
+				if _, ok := ruleContext.PrefixLists[*rule.PrefixListId]; !ok {
+					cidrs, err := ec2Client.GetManagedPrefixListEntries(ctx, *rule.PrefixListId)
+					if err != nil {
+						return ruleContext, err
+					}
+					ruleContext.PrefixLists[*rule.PrefixListId] = cidrs
+				}
+			}
+
+			if rule.ReferencedGroupId == nil || slices.Contains(knownIds, *rule.ReferencedGroupId) ||
+				slices.Contains(referencedIds, *rule.ReferencedGroupId) {
+				continue
+			}
+			if rule.ReferencedGroupOwnerId != nil && t.accountId != "" && *rule.ReferencedGroupOwnerId != t.accountId {
+				continue
+			}
+			referencedIds = append(referencedIds, *rule.ReferencedGroupId)
+		}
+	}
+
+	existingIds, err := ec2Client.GetExistingSecurityGroupIds(ctx, referencedIds)
+	if err != nil {
+		return ruleContext, err
+	}
+	for _, id := range missingIds(referencedIds, existingIds) {
+		ruleContext.MissingSecurityGroups[id] = true
+	}
+
+	return ruleContext, nil
+}
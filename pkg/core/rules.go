@@ -0,0 +1,68 @@
+package core
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+)
+
+// Convert the Security Group Rules returned by the EC2 API
+func toSecurityGroupRules(rules []ec2Types.SecurityGroupRule) []coreTypes.SecurityGroupRule {
+	converted := make([]coreTypes.SecurityGroupRule, 0, len(rules))
+	for _, rule := range rules {
+		converted = append(converted, toSecurityGroupRule(rule))
+	}
+	return converted
+}
+
+func toSecurityGroupRule(rule ec2Types.SecurityGroupRule) coreTypes.SecurityGroupRule {
+	converted := coreTypes.SecurityGroupRule{
+		Id:           aws.ToString(rule.SecurityGroupRuleId),
+		GroupId:      aws.ToString(rule.GroupId),
+		IsEgress:     aws.ToBool(rule.IsEgress),
+		Protocol:     aws.ToString(rule.IpProtocol),
+		FromPort:     aws.ToInt32(rule.FromPort),
+		ToPort:       aws.ToInt32(rule.ToPort),
+		CidrIpv4:     rule.CidrIpv4,
+		CidrIpv6:     rule.CidrIpv6,
+		PrefixListId: rule.PrefixListId,
+		Description:  rule.Description,
+	}
+	if rule.ReferencedGroupInfo != nil {
+		converted.ReferencedGroupId = rule.ReferencedGroupInfo.GroupId
+		converted.ReferencedGroupOwnerId = rule.ReferencedGroupInfo.UserId
+	}
+	return converted
+}
+
+// Convert a Security Group Rule to an IP permission which can be used to authorize it. References to the groups which
+// are keys of replacedIds are replaced with the corresponding values, so rules referencing recreated Security Groups,
+// including self-referencing rules, can be authorized again
+func toIpPermission(rule coreTypes.SecurityGroupRule, replacedIds map[string]string) ec2Types.IpPermission {
+	permission := ec2Types.IpPermission{
+		IpProtocol: aws.String(rule.Protocol),
+		FromPort:   aws.Int32(rule.FromPort),
+		ToPort:     aws.Int32(rule.ToPort),
+	}
+
+	switch {
+	case rule.CidrIpv4 != nil:
+		permission.IpRanges = []ec2Types.IpRange{{CidrIp: rule.CidrIpv4, Description: rule.Description}}
+	case rule.CidrIpv6 != nil:
+		permission.Ipv6Ranges = []ec2Types.Ipv6Range{{CidrIpv6: rule.CidrIpv6, Description: rule.Description}}
+	case rule.PrefixListId != nil:
+		permission.PrefixListIds = []ec2Types.PrefixListId{{PrefixListId: rule.PrefixListId, Description: rule.Description}}
+	case rule.ReferencedGroupId != nil:
+		groupId := *rule.ReferencedGroupId
+		if newGroupId, ok := replacedIds[groupId]; ok {
+			groupId = newGroupId
+		}
+		permission.UserIdGroupPairs = []ec2Types.UserIdGroupPair{{
+			GroupId:     aws.String(groupId),
+			UserId:      rule.ReferencedGroupOwnerId,
+			Description: rule.Description,
+		}}
+	}
+
+	return permission
+}
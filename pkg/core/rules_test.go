@@ -0,0 +1,89 @@
+package core
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestToIpPermission(t *testing.T) {
+	newRule := func(rule ec2Types.SecurityGroupRule) ec2Types.SecurityGroupRule {
+		rule.SecurityGroupRuleId = aws.String("sgr-1")
+		rule.GroupId = aws.String("sg-a")
+		rule.IpProtocol = aws.String("tcp")
+		rule.FromPort = aws.Int32(443)
+		rule.ToPort = aws.Int32(443)
+		rule.Description = aws.String("HTTPS")
+		return rule
+	}
+	newPermission := func(permission ec2Types.IpPermission) ec2Types.IpPermission {
+		permission.IpProtocol = aws.String("tcp")
+		permission.FromPort = aws.Int32(443)
+		permission.ToPort = aws.Int32(443)
+		return permission
+	}
+	replacedIds := map[string]string{"sg-old": "sg-new"}
+
+	tests := []struct {
+		name       string
+		rule       ec2Types.SecurityGroupRule
+		permission ec2Types.IpPermission
+	}{
+		{
+			name: "IPv4 range",
+			rule: newRule(ec2Types.SecurityGroupRule{CidrIpv4: aws.String("10.0.0.0/8")}),
+			permission: newPermission(ec2Types.IpPermission{
+				IpRanges: []ec2Types.IpRange{{CidrIp: aws.String("10.0.0.0/8"), Description: aws.String("HTTPS")}},
+			}),
+		},
+		{
+			name: "IPv6 range",
+			rule: newRule(ec2Types.SecurityGroupRule{CidrIpv6: aws.String("::/0")}),
+			permission: newPermission(ec2Types.IpPermission{
+				Ipv6Ranges: []ec2Types.Ipv6Range{{CidrIpv6: aws.String("::/0"), Description: aws.String("HTTPS")}},
+			}),
+		},
+		{
+			name: "prefix list",
+			rule: newRule(ec2Types.SecurityGroupRule{PrefixListId: aws.String("pl-1")}),
+			permission: newPermission(ec2Types.IpPermission{
+				PrefixListIds: []ec2Types.PrefixListId{{PrefixListId: aws.String("pl-1"), Description: aws.String("HTTPS")}},
+			}),
+		},
+		{
+			name: "referenced group",
+			rule: newRule(ec2Types.SecurityGroupRule{ReferencedGroupInfo: &ec2Types.ReferencedSecurityGroup{
+				GroupId: aws.String("sg-b"),
+				UserId:  aws.String("111111111111"),
+			}}),
+			permission: newPermission(ec2Types.IpPermission{
+				UserIdGroupPairs: []ec2Types.UserIdGroupPair{{
+					GroupId:     aws.String("sg-b"),
+					UserId:      aws.String("111111111111"),
+					Description: aws.String("HTTPS"),
+				}},
+			}),
+		},
+		{
+			name: "replaced referenced group",
+			rule: newRule(ec2Types.SecurityGroupRule{ReferencedGroupInfo: &ec2Types.ReferencedSecurityGroup{
+				GroupId: aws.String("sg-old"),
+				UserId:  aws.String("111111111111"),
+			}}),
+			permission: newPermission(ec2Types.IpPermission{
+				UserIdGroupPairs: []ec2Types.UserIdGroupPair{{
+					GroupId:     aws.String("sg-new"),
+					UserId:      aws.String("111111111111"),
+					Description: aws.String("HTTPS"),
+				}},
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.permission, toIpPermission(toSecurityGroupRule(tt.rule), replacedIds))
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
+	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
+	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
+	"strings"
+	"sync"
+)
+
+// Scope describes where sg-ripper looks for resources
+type Scope struct {
+	// Profile is the shared configuration profile used for loading the credentials
+	Profile string
+	// Regions to be scanned. If it is empty, the region from the shared configuration is used
+	Regions []string
+	// AllRegions scans every region enabled for the account. Regions is ignored if it is set
+	AllRegions bool
+	// Accounts to be scanned. If it is empty, only the account of the profile is scanned
+	Accounts []string
+	// OrganizationAccounts scans every active member account of the AWS Organization in addition to Accounts. The
+	// profile has to belong to the management account or to a delegated administrator account
+	OrganizationAccounts bool
+	// RoleName is the name of the IAM role assumed in every account other than the account of the profile
+	RoleName string
+	// Protection marks the resources which must never be removed. A Scope created by NewScope is protected by
+	// DefaultProtection, an empty Protection protects no resource
+	Protection Protection
+}
+
+// NewScope creates a Scope for a single region, protecting the resources tagged with the DefaultProtectionTag. If the
+// region is empty, the region from the shared configuration is used
+func NewScope(region string, profile string) Scope {
+	scope := Scope{Profile: profile, Protection: DefaultProtection()}
+	if region != "" {
+		scope.Regions = []string{region}
+	}
+	return scope
+}
+
+// A single account and region of a Scope together with the AWS configuration used for accessing it
+type target struct {
+	accountId string
+	cfg       aws.Config
+}
+
+func (t target) region() string {
+	return t.cfg.Region
+}
+
+func (t target) location() location {
+	return location{accountId: t.accountId, region: t.region()}
+}
+
+func (t target) String() string {
+	if t.region() == "" {
+		return t.accountId
+	}
+	return t.location().String()
+}
+
+// The account and the region in which a resource exists
+type location struct {
+	accountId string
+	region    string
+}
+
+func (l location) String() string {
+	return l.accountId + "/" + l.region
+}
+
+// Resolve the accounts and the regions of the Scope into the configurations used for accessing them. The accounts in
+// which the enabled regions cannot be discovered are reported by a ScanError together with the other targets
+func resolveTargets(ctx context.Context, scope Scope) ([]target, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithSharedConfigProfile(scope.Profile))
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := getDiscoveryRegion(cfg, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := resolveAccounts(ctx, cfg, region, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return forEachTarget(ctx, accounts, func(ctx context.Context, account target) ([]target, error) {
+		regions := scope.Regions
+		if scope.AllRegions {
+			discoveryCfg := account.cfg.Copy()
+			discoveryCfg.Region = region
+
+			var err error
+			regions, err = clients.NewAwsEc2Client(discoveryCfg).DescribeRegions(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if len(regions) == 0 {
+			return []target{account}, nil
+		}
+
+		targets := make([]target, 0, len(regions))
+		for _, region := range regions {
+			regionCfg := account.cfg.Copy()
+			regionCfg.Region = region
+			targets = append(targets, target{accountId: account.accountId, cfg: regionCfg})
+		}
+		return targets, nil
+	})
+}
+
+// Get the region used for identifying the caller, listing the accounts of the organization and discovering the enabled
+// regions. The region of the profile is used, or the first region of the Scope if the profile has none, so that the
+// endpoints of the partition of the profile are called
+func getDiscoveryRegion(cfg aws.Config, scope Scope) (string, error) {
+	if cfg.Region != "" {
+		return cfg.Region, nil
+	}
+	if len(scope.Regions) > 0 {
+		return scope.Regions[0], nil
+	}
+	return "", fmt.Errorf("no region is configured for the profile and no region is provided")
+}
+
+// Resolve the accounts of the Scope. The account of the profile is accessed with the credentials of the profile,
+// every other account is accessed by assuming the role of the Scope
+func resolveAccounts(ctx context.Context, cfg aws.Config, region string, scope Scope) ([]target, error) {
+	identityCfg := cfg.Copy()
+	identityCfg.Region = region
+
+	stsClient := clients.NewAwsStsClient(identityCfg)
+	identity, err := stsClient.GetCallerIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIds := append([]string{}, scope.Accounts...)
+	if scope.OrganizationAccounts {
+		organizationAccountIds, err := clients.NewAwsOrganizationsClient(identityCfg).ListActiveAccountIds(ctx)
+		if err != nil {
+			return nil, err
+		}
+		accountIds = append(accountIds, organizationAccountIds...)
+	}
+
+	if len(accountIds) == 0 {
+		return []target{{accountId: identity.AccountID, cfg: cfg}}, nil
+	}
+
+	accounts := make([]target, 0, len(accountIds))
+	seen := make(map[string]bool, len(accountIds))
+	for _, accountId := range accountIds {
+		if seen[accountId] {
+			continue
+		}
+		seen[accountId] = true
+
+		if accountId == identity.AccountID {
+			accounts = append(accounts, target{accountId: accountId, cfg: cfg})
+			continue
+		}
+
+		if scope.RoleName == "" {
+			return nil, fmt.Errorf("no role name provided for accessing the account %s", accountId)
+		}
+
+		accountCfg := cfg.Copy()
+		accountCfg.Credentials = stsClient.AssumeRoleCredentials(
+			fmt.Sprintf("arn:%s:iam::%s:role/%s", identity.Partition, accountId, scope.RoleName))
+		accounts = append(accounts, target{accountId: accountId, cfg: accountCfg})
+	}
+	return accounts, nil
+}
+
+// TargetError reports an account and a region of a Scope which could not be scanned. The region is the one used for
+// discovering the enabled regions if the regions of the account could not be discovered
+type TargetError struct {
+	AccountId string
+	Region    string
+	Err       error
+}
+
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("%s: %v", location{accountId: e.AccountId, region: e.Region}, e.Err)
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// ScanError reports the accounts and the regions of a Scope which could not be scanned, for example because the role
+// cannot be assumed or the access is denied. The functions returning a ScanError also return the results of the
+// accounts and the regions which were scanned successfully
+type ScanError struct {
+	Errors []*TargetError
+}
+
+func (e *ScanError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *ScanError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Split an error returned by forEachTarget into the targets which failed and an error which is fatal for the caller
+func asScanError(err error) (*ScanError, error) {
+	var scanErr *ScanError
+	if err == nil || !errors.As(err, &scanErr) {
+		return nil, err
+	}
+	return scanErr, nil
+}
+
+// Merge the targets which failed into a single ScanError, returning nil if no target failed
+func joinScanErrors(scanErrs ...*ScanError) error {
+	joined := &ScanError{}
+	for _, scanErr := range scanErrs {
+		if scanErr != nil {
+			joined.Errors = append(joined.Errors, scanErr.Errors...)
+		}
+	}
+	if len(joined.Errors) == 0 {
+		return nil
+	}
+	return joined
+}
+
+// Call fn concurrently for every target. The results are returned in the order of the targets. The targets for which
+// fn fails are reported by a ScanError, returned together with the results of the other targets. If every target
+// fails, only the errors are returned
+func forEachTarget[T any](ctx context.Context, targets []target, fn func(context.Context, target) ([]T, error)) ([]T, error) {
+	results := make([][]T, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		i, t := i, t // capture values
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fn(ctx, t)
+		}()
+	}
+	wg.Wait()
+
+	merged := make([]T, 0)
+	scanErr := &ScanError{}
+	for i, t := range targets {
+		if errs[i] != nil {
+			scanErr.Errors = append(scanErr.Errors,
+				&TargetError{AccountId: t.accountId, Region: t.region(), Err: errs[i]})
+			continue
+		}
+		merged = append(merged, results[i]...)
+	}
+
+	switch {
+	case len(scanErr.Errors) == 0:
+		return merged, nil
+	case len(scanErr.Errors) == len(targets):
+		return nil, errors.Join(scanErr.Unwrap()...)
+	default:
+		return merged, scanErr
+	}
+}
+
+// Resolve the targets of the Scope and call fn concurrently for every one of them. The targets are returned together
+// with the results. The accounts and the regions which cannot be resolved or scanned are reported by a ScanError,
+// returned together with the results of the other ones
+func scanTargets[T any](ctx context.Context, scope Scope,
+	fn func(context.Context, target) ([]T, error)) ([]T, []target, error) {
+	targets, err := resolveTargets(ctx, scope)
+	resolveErr, err := asScanError(err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, err := forEachTarget(ctx, targets, fn)
+	scanErr, err := asScanError(err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, targets, joinScanErrors(resolveErr, scanErr)
+}
+
+// Convert an error returned by scanTargets into the errors of the targets which failed, so that they can be reported
+// together with the removal results. Any other error is returned as it is
+func scanErrorsOf(err error) ([]error, error) {
+	scanErr, err := asScanError(err)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0)
+	if scanErr != nil {
+		errs = append(errs, scanErr.Unwrap()...)
+	}
+	return errs, nil
+}
+
+// A removal running in a single target
+type removal struct {
+	target   target
+	resultCh chan utils.Result[string]
+}
+
+// Report the errors provided, then forward every result of the removals to the destination channel, tagging them
+// with the account and the region of the removal. The destination channel is closed after every removal is finished
+func forwardResults(errs []error, removals []removal, destinationCh chan utils.Result[coreTypes.ResourceRef]) {
+	go func() {
+		defer close(destinationCh)
+
+		for _, err := range errs {
+			destinationCh <- utils.Result[coreTypes.ResourceRef]{Err: err}
+		}
+
+		var wg sync.WaitGroup
+		for _, r := range removals {
+			r := r // capture value
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for res := range r.resultCh {
+					if res.Err != nil {
+						destinationCh <- utils.Result[coreTypes.ResourceRef]{
+							Err: fmt.Errorf("%s: %w", r.target, res.Err),
+						}
+					} else {
+						destinationCh <- utils.Result[coreTypes.ResourceRef]{
+							Data: coreTypes.ResourceRef{
+								Id:        res.Data,
+								AccountId: r.target.accountId,
+								Region:    r.target.region(),
+							},
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+}
+
+// Get the error reporting the IDs which were requested, but were not found. If some accounts or regions could not be
+// scanned, the IDs might exist in one of them, so their errors are included
+func notFoundError(resourceName string, missing []string, scanErr error) error {
+	if scanErr != nil {
+		return fmt.Errorf("%s not found: %s\n%v", resourceName, strings.Join(missing, ", "), scanErr)
+	}
+	return fmt.Errorf("%s not found: %s", resourceName, strings.Join(missing, ", "))
+}
+
+// Return the IDs which were requested, but were not found
+func missingIds(requested []string, found []string) []string {
+	foundIds := make(map[string]bool, len(found))
+	for _, id := range found {
+		foundIds[id] = true
+	}
+
+	missing := make([]string, 0)
+	for _, id := range requested {
+		if !foundIds[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestForEachTarget(t *testing.T) {
+	newTarget := func(accountId string, region string) target {
+		return target{accountId: accountId, cfg: aws.Config{Region: region}}
+	}
+	targets := []target{
+		newTarget("111111111111", "eu-west-1"),
+		newTarget("111111111111", "us-east-1"),
+		newTarget("222222222222", "eu-west-1"),
+	}
+	accessDenied := errors.New("access denied")
+
+	tests := []struct {
+		name       string
+		failing    map[string]bool
+		results    []string
+		failed     []string
+		allFailing bool
+	}{
+		{
+			name:    "every target succeeds",
+			results: []string{"111111111111/eu-west-1", "111111111111/us-east-1", "222222222222/eu-west-1"},
+		},
+		{
+			name:    "partial failure",
+			failing: map[string]bool{"111111111111/us-east-1": true},
+			results: []string{"111111111111/eu-west-1", "222222222222/eu-west-1"},
+			failed:  []string{"111111111111/us-east-1"},
+		},
+		{
+			name: "every target fails",
+			failing: map[string]bool{
+				"111111111111/eu-west-1": true,
+				"111111111111/us-east-1": true,
+				"222222222222/eu-west-1": true,
+			},
+			allFailing: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := forEachTarget(context.Background(), targets,
+				func(ctx context.Context, t target) ([]string, error) {
+					if tt.failing[t.String()] {
+						return nil, accessDenied
+					}
+					return []string{t.String()}, nil
+				})
+
+			switch {
+			case tt.allFailing:
+				require.ErrorIs(t, err, accessDenied)
+				scanErr, fatalErr := asScanError(err)
+				require.Nil(t, scanErr)
+				require.Error(t, fatalErr)
+				require.Nil(t, results)
+			case len(tt.failed) > 0:
+				scanErr, fatalErr := asScanError(err)
+				require.NoError(t, fatalErr)
+				require.NotNil(t, scanErr)
+
+				failed := make([]string, 0, len(scanErr.Errors))
+				for _, targetErr := range scanErr.Errors {
+					require.ErrorIs(t, targetErr, accessDenied)
+					failed = append(failed, location{accountId: targetErr.AccountId, region: targetErr.Region}.String())
+				}
+				require.Equal(t, tt.failed, failed)
+				require.Equal(t, tt.results, results)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tt.results, results)
+			}
+		})
+	}
+}
@@ -2,35 +2,46 @@ package core
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"fmt"
 	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/builders"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/clients"
 	coreTypes "github.com/cloud-crafts/sg-ripper/pkg/core/types"
 	"github.com/cloud-crafts/sg-ripper/pkg/core/utils"
+	"strings"
 )
 
-const (
-	All SecurityGroupStatus = iota
-	Used
-	Unused
-)
-
-type SecurityGroupStatus int
-
-type Filters struct {
-	Status SecurityGroupStatus
-}
-
 // ListSecurityGroups returns a slice of SecurityGroupDetails based on the input Security Group ID list and filters.
 // If the slice with the IDs is empty, all the security groups will be retrieved
 func ListSecurityGroups(ctx context.Context, securityGroupIds []string, filters Filters, region string, profile string) ([]coreTypes.SecurityGroupDetails, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region), config.WithSharedConfigProfile(profile))
-	if err != nil {
+	return ListSecurityGroupsInScope(ctx, securityGroupIds, filters, NewScope(region, profile))
+}
+
+// ListSecurityGroupsInScope returns a slice of SecurityGroupDetails from every account and region of the scope based
+// on the input Security Group ID list and filters. The accounts and regions are scanned concurrently. If the slice with
+// the IDs is empty, all the security groups will be retrieved, otherwise an error is returned if any of the IDs is not
+// found anywhere. The accounts and regions which cannot be scanned are reported by a ScanError, returned together with
+// the Security Groups of the other ones
+func ListSecurityGroupsInScope(ctx context.Context, securityGroupIds []string, filters Filters, scope Scope) ([]coreTypes.SecurityGroupDetails, error) {
+	groups, _, scanErr := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.SecurityGroupDetails, error) {
+		return listSecurityGroups(ctx, t, securityGroupIds, scope.Protection)
+	})
+	if _, err := asScanError(scanErr); err != nil {
 		return nil, err
 	}
 
-	ec2Client := clients.NewAwsEc2Client(cfg)
+	if missing := missingIds(securityGroupIds, securityGroupIdsOf(groups)); len(missing) > 0 {
+		return nil, notFoundError("security group(s)", missing, scanErr)
+	}
+
+	return applyFilters(groups, filters), scanErr
+}
+
+// List the Security Groups from the account and the region of the target, marking the protected ones. IDs which are not
+// found are ignored
+func listSecurityGroups(ctx context.Context, t target, securityGroupIds []string,
+	protection Protection) ([]coreTypes.SecurityGroupDetails, error) {
+	ec2Client := clients.NewAwsEc2Client(t.cfg)
 
 	securityGroupRules, err := ec2Client.DescribeSecurityGroupRules(ctx)
 	if err != nil {
@@ -42,10 +53,12 @@ func ListSecurityGroups(ctx context.Context, securityGroupIds []string, filters
 		return nil, err
 	}
 
+	configurationReferences := getConfigurationReferences(ctx, t)
+
 	sgResultCh := make(chan utils.Result[[]ec2Types.SecurityGroup])
 	ec2Client.DescribeSecurityGroups(ctx, securityGroupIds, sgResultCh)
 
-	eniDetailsBuilder := builders.NewEniBuilder(cfg)
+	eniDetailsBuilder := builders.NewEniBuilder(t.cfg)
 
 	groups := make([]coreTypes.SecurityGroupDetails, 0)
 	for sgResult := range sgResultCh {
@@ -60,13 +73,31 @@ func ListSecurityGroups(ctx context.Context, securityGroupIds []string, filters
 				return nil, err
 			}
 
-			groups = append(groups,
-				*coreTypes.NewSecurityGroup(*sg.GroupName, *sg.GroupId, *sg.Description, enis,
-					getRuleReferences(sg, securityGroupRules), *sg.VpcId))
+			group := coreTypes.NewSecurityGroup(*sg.GroupName, *sg.GroupId, *sg.Description, enis,
+				getRuleReferences(sg, securityGroupRules), *sg.VpcId)
+			group.Tags = utils.TagsToMap(sg.Tags)
+			group.Rules = getRules(sg, securityGroupRules)
+			group.ConfigurationReferences = configurationReferences.get(sg)
+			if len(configurationReferences.unchecked) > 0 {
+				group.UncheckedConfigurations = configurationReferences.unchecked
+			}
+			group.AccountId = t.accountId
+			group.Region = t.region()
+			groups = append(groups, *group)
 		}
 	}
 
-	return applyFilters(groups, filters), nil
+	protection.protectSecurityGroups(groups)
+	markRemovalSets(groups)
+	return groups, nil
+}
+
+func securityGroupIdsOf(groups []coreTypes.SecurityGroupDetails) []string {
+	ids := make([]string, 0, len(groups))
+	for _, sg := range groups {
+		ids = append(ids, sg.Id)
+	}
+	return ids
 }
 
 // Get all the Network Interfaces which are associated to one of the Security Groups from the input list
@@ -96,62 +127,184 @@ func getRuleReferences(sg ec2Types.SecurityGroup, securityGroupRules []ec2Types.
 	return sgIds
 }
 
-// Apply Filters to the list of Security Group usages
-func applyFilters(groups []coreTypes.SecurityGroupDetails, filters Filters) []coreTypes.SecurityGroupDetails {
-	if filters.Status == All {
-		return groups
+// Get the inbound and outbound rules of the Security Group
+func getRules(sg ec2Types.SecurityGroup, securityGroupRules []ec2Types.SecurityGroupRule) []coreTypes.SecurityGroupRule {
+	rules := make([]coreTypes.SecurityGroupRule, 0)
+	for _, rule := range securityGroupRules {
+		if rule.GroupId != nil && *rule.GroupId == *sg.GroupId {
+			rules = append(rules, toSecurityGroupRule(rule))
+		}
 	}
+	return rules
+}
 
-	var filterFn func(sg coreTypes.SecurityGroupDetails) bool
+// RemoveOptions controls how Security Groups are removed
+type RemoveOptions struct {
+	// Force skips the usage check and attempts to remove every Security Group provided by ID. Security Groups selected
+	// by filters only are always checked
+	Force bool
+	// BackupDir is the directory in which every Security Group is backed up before its removal. If it is empty, no
+	// backup is made
+	BackupDir string
+	// Cascade revokes the rules of other Security Groups referencing the Security Groups to be removed, then removes
+	// them one after the other in the order of their dependencies. Security Groups provided by ID are removed if they
+	// are only blocked by rule references, Security Groups selected by filters only if they are removable as a set. If
+	// a backup directory is provided, the revoked rules are backed up into it and can be restored with
+	// RestoreRevokedRules
+	Cascade bool
+	// OnRuleRevoked is called for every rule revoked by a cascading removal
+	OnRuleRevoked func(rule coreTypes.RevokedRule)
+}
 
-	switch filters.Status {
-	case Used:
-		filterFn = func(sg coreTypes.SecurityGroupDetails) bool {
-			return sg.IsInUse()
-		}
-	case Unused:
-		filterFn = func(sg coreTypes.SecurityGroupDetails) bool {
-			return !sg.IsInUse()
-		}
+// RemoveSecurityGroupsAsync removes Security Groups based on the input list provided from every account and region of
+// the scope. If the list is empty, every Security Group matching the filters which can be removed is selected,
+// otherwise the Security Groups which do not match the filters are skipped. Unless forced, every Security Group is
+// evaluated the same way as in ListSecurityGroups and the ones which cannot be removed are refused, the reasons being
+// reported as errors on the result channel together with the accounts and regions which cannot be scanned. If a backup
+// directory is provided, Security Groups which cannot be backed up are not removed. This function expects a result
+// channel for being able to provide removal information for the caller. Protected Security Groups are always refused,
+// even if the removal is forced or cascading
+func RemoveSecurityGroupsAsync(ctx context.Context, securityGroupIds []string, filters Filters, options RemoveOptions,
+	scope Scope, resultCh chan utils.Result[coreTypes.ResourceRef]) error {
+	groups, targets, err := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.SecurityGroupDetails, error) {
+		return listSecurityGroups(ctx, t, securityGroupIds, scope.Protection)
+	})
+	refused, err := scanErrorsOf(err)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range missingIds(securityGroupIds, securityGroupIdsOf(groups)) {
+		refused = append(refused, fmt.Errorf("security group %s not found", id))
 	}
 
-	filteredGroups := make([]coreTypes.SecurityGroupDetails, 0)
+	removable := make(map[location][]string)
 	for _, sg := range groups {
-		if filterFn(sg) {
-			filteredGroups = append(filteredGroups, sg)
+		sgLocation := location{accountId: sg.AccountId, region: sg.Region}
+		switch {
+		case !filters.matchesSecurityGroup(sg):
+			if len(securityGroupIds) > 0 {
+				refused = append(refused, fmt.Errorf("%s: skipping Security Group %s: it does not match the filters",
+					sgLocation, sg.Id))
+			}
+		case sg.IsProtected():
+			refused = append(refused, fmt.Errorf("%s: refusing to remove Security Group %s: it is protected by %s",
+				sgLocation, sg.Id, sg.ProtectedBy))
+		case sg.CanBeRemoved() || (options.Force && len(securityGroupIds) > 0):
+			removable[sgLocation] = append(removable[sgLocation], sg.Id)
+		case options.Cascade && len(securityGroupIds) > 0 && sg.IsBlockedOnlyByRules():
+			removable[sgLocation] = append(removable[sgLocation], sg.Id)
+		case options.Cascade && sg.IsRemovableAsSet():
+			removable[sgLocation] = append(removable[sgLocation], sg.Id)
+		case len(securityGroupIds) > 0:
+			refused = append(refused, fmt.Errorf("%s: refusing to remove Security Group %s: %s", sgLocation, sg.Id,
+				strings.Join(sg.ReasonsAgainstRemoval(), "; ")))
 		}
 	}
-	return filteredGroups
+
+	return removeSecurityGroups(ctx, targets, removable, options, refused, resultCh)
 }
 
-// RemoveSecurityGroupsAsync removes Security Groups based on the input list provided. This function expects a result
-// channel for being able to provide removal information for the caller
-func RemoveSecurityGroupsAsync(ctx context.Context, securityGroupIds []string, region string, profile string,
-	resultCh chan utils.Result[string]) error {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region), config.WithSharedConfigProfile(profile))
-	if err != nil {
-		return err
-	}
+// Remove the Security Groups grouped by the location of the target. The errors provided are reported on the result
+// channel before the removal results
+func removeSecurityGroups(ctx context.Context, targets []target, securityGroupIds map[location][]string,
+	options RemoveOptions, errs []error, resultCh chan utils.Result[coreTypes.ResourceRef]) error {
+	removals := make([]removal, 0, len(targets))
+	for _, t := range targets {
+		ids := securityGroupIds[t.location()]
+		if len(ids) == 0 {
+			continue
+		}
 
-	ec2Client := clients.NewAwsEc2Client(cfg)
+		if options.BackupDir != "" {
+			var backupErrs []error
+			var err error
+			ids, backupErrs, err = backupSecurityGroups(ctx, t, ids, options.BackupDir)
+			if err != nil {
+				return fmt.Errorf("%s: %w", t, err)
+			}
+			for _, backupErr := range backupErrs {
+				errs = append(errs, fmt.Errorf("%s: %w", t, backupErr))
+			}
+		}
 
-	ec2Client.TryRemoveAllSecurityGroups(ctx, securityGroupIds, resultCh)
+		removalCh := make(chan utils.Result[string])
+		if options.Cascade {
+			var revokeErrs []error
+			var err error
+			ids, revokeErrs, err = revokeReferencingRules(ctx, t, ids, options.BackupDir, options.OnRuleRevoked)
+			if err != nil {
+				return fmt.Errorf("%s: %w", t, err)
+			}
+			for _, revokeErr := range revokeErrs {
+				errs = append(errs, fmt.Errorf("%s: %w", t, revokeErr))
+			}
+			clients.NewAwsEc2Client(t.cfg).RemoveSecurityGroupsInOrder(ctx, ids, removalCh)
+		} else {
+			clients.NewAwsEc2Client(t.cfg).TryRemoveAllSecurityGroups(ctx, ids, removalCh)
+		}
+		removals = append(removals, removal{target: t, resultCh: removalCh})
+	}
 
+	forwardResults(errs, removals, resultCh)
 	return nil
 }
 
-// RemoveENIAsync removes Elastic Network Interfaces based on the input list provided. This function expects a result
-// channel for being able to provide removal information for the caller
-func RemoveENIAsync(ctx context.Context, eniIds []string, region string, profile string,
-	resultCh chan utils.Result[string]) error {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region), config.WithSharedConfigProfile(profile))
+// RemoveENIAsync removes Elastic Network Interfaces based on the input list provided from every account and region of
+// the scope. If the list is empty, every Network Interface matching the filters which is not in use is selected,
+// otherwise the Network Interfaces which do not match the filters are skipped. Protected Network Interfaces are always
+// refused, the accounts and regions which cannot be scanned are reported as errors on the result channel. This function
+// expects a result channel for being able to provide removal information for the caller
+func RemoveENIAsync(ctx context.Context, eniIds []string, filters Filters, scope Scope,
+	resultCh chan utils.Result[coreTypes.ResourceRef]) error {
+	enis, targets, err := scanTargets(ctx, scope, func(ctx context.Context, t target) ([]coreTypes.NetworkInterfaceDetails, error) {
+		return listNetworkInterfaces(ctx, t, eniIds, scope.Protection)
+	})
+	errs, err := scanErrorsOf(err)
 	if err != nil {
 		return err
 	}
 
-	ec2Client := clients.NewAwsEc2Client(cfg)
+	for _, id := range missingIds(eniIds, networkInterfaceIdsOf(enis)) {
+		errs = append(errs, fmt.Errorf("network interface %s not found", id))
+	}
 
-	ec2Client.TryRemoveAllENIs(ctx, eniIds, resultCh)
+	removable := make(map[location][]string)
+	for _, eni := range enis {
+		eniLocation := location{accountId: eni.AccountId, region: eni.Region}
+		switch {
+		case !filters.matchesNetworkInterface(eni):
+			if len(eniIds) > 0 {
+				errs = append(errs, fmt.Errorf("%s: skipping Network Interface %s: it does not match the filters",
+					eniLocation, eni.Id))
+			}
+		case eni.IsProtected():
+			errs = append(errs, fmt.Errorf("%s: refusing to remove Network Interface %s: it is protected by %s",
+				eniLocation, eni.Id, eni.ProtectedBy))
+		case len(eniIds) > 0 || eni.CanBeRemoved():
+			removable[eniLocation] = append(removable[eniLocation], eni.Id)
+		}
+	}
 
+	removeNetworkInterfaces(ctx, targets, removable, errs, resultCh)
 	return nil
 }
+
+// Remove the Network Interfaces grouped by the location of the target. The errors provided are reported on the result
+// channel before the removal results
+func removeNetworkInterfaces(ctx context.Context, targets []target, eniIds map[location][]string, errs []error,
+	resultCh chan utils.Result[coreTypes.ResourceRef]) {
+	removals := make([]removal, 0, len(targets))
+	for _, t := range targets {
+		ids := eniIds[t.location()]
+		if len(ids) == 0 {
+			continue
+		}
+
+		removalCh := make(chan utils.Result[string])
+		clients.NewAwsEc2Client(t.cfg).TryRemoveAllENIs(ctx, ids, removalCh)
+		removals = append(removals, removal{target: t, resultCh: removalCh})
+	}
+
+	forwardResults(errs, removals, resultCh)
+}
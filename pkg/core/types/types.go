@@ -1,96 +1,778 @@
 package types
 
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"strings"
+)
+
+// ResourceRef identifies a Security Group or a Network Interface together with the account and the region it belongs to
+type ResourceRef struct {
+	Id        string `json:"id"`
+	AccountId string `json:"accountId"`
+	Region    string `json:"region"`
+}
+
+func (r ResourceRef) String() string {
+	return fmt.Sprintf("%s (%s/%s)", r.Id, r.AccountId, r.Region)
+}
+
 type SecurityGroupDetails struct {
-	Name           string
-	Id             string
-	Description    string
-	Default        bool
-	UsedBy         []NetworkInterfaceDetails
-	RuleReferences []string
-	VpcId          string
+	Name                    string                    `json:"name"`
+	Id                      string                    `json:"id"`
+	Description             string                    `json:"description"`
+	Default                 bool                      `json:"default"`
+	UsedBy                  []NetworkInterfaceDetails `json:"usedBy"`
+	RuleReferences          []string                  `json:"ruleReferences"`
+	ConfigurationReferences []ConfigurationReference  `json:"configurationReferences"`
+	UncheckedConfigurations []string                  `json:"uncheckedConfigurations,omitempty"`
+	Rules                   []SecurityGroupRule       `json:"rules"`
+	RemovalSet              []string                  `json:"removalSet,omitempty"`
+	RemovalSetHasCycles     bool                      `json:"removalSetHasCycles,omitempty"`
+	VpcId                   string                    `json:"vpcId"`
+	Tags                    map[string]string         `json:"tags"`
+	ProtectedBy             string                    `json:"protectedBy,omitempty"`
+	AccountId               string                    `json:"accountId"`
+	Region                  string                    `json:"region"`
+}
+
+const (
+	LaunchTemplateReference      = "launch-template"
+	LaunchConfigurationReference = "launch-configuration"
+	AutoScalingGroupReference    = "auto-scaling-group"
+	LambdaFunctionReference      = "lambda-function"
+	EcsServiceReference          = "ecs-service"
+	EcsScheduledTaskReference    = "ecs-scheduled-task"
+	EcsScheduleReference         = "ecs-schedule"
+	EcsCapacityProviderReference = "ecs-capacity-provider"
+)
+
+// ConfigurationReference is a configuration which will create Network Interfaces using a Security Group, even if no
+// Network Interface is using it at the moment. Type is one of LaunchTemplateReference, LaunchConfigurationReference,
+// AutoScalingGroupReference, LambdaFunctionReference, EcsServiceReference, EcsScheduledTaskReference,
+// EcsScheduleReference or EcsCapacityProviderReference. Alias is set only for the aliases of Lambda functions, together
+// with the version they point to.
+type ConfigurationReference struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Id      string `json:"id,omitempty"`
+	Version string `json:"version,omitempty"`
+	Alias   string `json:"alias,omitempty"`
+}
+
+// Label returns a short description of the configuration
+func (r ConfigurationReference) Label() string {
+	label := fmt.Sprintf("%s %s", r.Type, r.Name)
+	if r.Version != "" {
+		label = fmt.Sprintf("%s version %s", label, r.Version)
+	}
+	if r.Alias != "" {
+		label = fmt.Sprintf("%s alias %s", label, r.Alias)
+	}
+	return label
 }
 
 // NewSecurityGroup creates a new SecurityGroupDetails object and returns a pointer to it
 func NewSecurityGroup(name string, id string, description string, usedBy []NetworkInterfaceDetails, ruleReferences []string,
 	vpcId string) *SecurityGroupDetails {
 	return &SecurityGroupDetails{
-		Name:           name,
-		Id:             id,
-		Description:    description,
-		RuleReferences: ruleReferences,
-		UsedBy:         usedBy,
-		VpcId:          vpcId,
-		Default:        name == "default",
+		Name:                    name,
+		Id:                      id,
+		Description:             description,
+		RuleReferences:          ruleReferences,
+		ConfigurationReferences: make([]ConfigurationReference, 0),
+		UsedBy:                  usedBy,
+		VpcId:                   vpcId,
+		Default:                 name == "default",
 	}
 }
 
-// IsInUse returns true if the Security Group is in use: it is used by at least one Network Interface, or
-// it is referenced by an SG inbound/outbound rule
+// IsInUse returns true if the Security Group is in use: it is used by at least one Network Interface, it is referenced
+// by an SG inbound/outbound rule, or it is referenced by a launch template, a launch configuration, an Auto Scaling
+// group, an ECS service, scheduled task or capacity provider, or a Lambda function
 func (u *SecurityGroupDetails) IsInUse() bool {
-	return len(u.UsedBy) > 0 || len(u.RuleReferences) > 0
+	return len(u.UsedBy) > 0 || len(u.RuleReferences) > 0 || len(u.ConfigurationReferences) > 0
+}
+
+// IsRemovableAsSet returns true if the Security Group is referenced only by rules of other unused Security Groups. The
+// Security Groups of the RemovalSet can be removed together, in the order of the set. If RemovalSetHasCycles is true,
+// some of them reference each other and the rules referencing them have to be revoked first
+func (u *SecurityGroupDetails) IsRemovableAsSet() bool {
+	return len(u.RemovalSet) > 0
+}
+
+// IsBlockedOnlyByRules returns true if the only reason against the removal of the Security Group is that it is
+// referenced by rules of Security Groups, which can be revoked by a cascading removal
+func (u *SecurityGroupDetails) IsBlockedOnlyByRules() bool {
+	return !u.Default && !u.IsProtected() && len(u.UsedBy) == 0 && len(u.ConfigurationReferences) == 0 &&
+		len(u.UncheckedConfigurations) == 0 && len(u.RuleReferences) > 0
 }
 
-// CanBeRemoved returns true if the Security Group can be removed, meaning it is not in use, or it is not a default SG
+// IsProtected returns true if the Security Group is protected from removal by a protection tag or a keep-list
+func (u *SecurityGroupDetails) IsProtected() bool {
+	return u.ProtectedBy != ""
+}
+
+// CanBeRemoved returns true if the Security Group can be removed, meaning it is not in use, it is not a default SG, it
+// is not protected and every source of configurations which could reference it was checked
 func (u *SecurityGroupDetails) CanBeRemoved() bool {
-	return !u.Default && !u.IsInUse()
+	return !u.Default && !u.IsInUse() && !u.IsProtected() && len(u.UncheckedConfigurations) == 0
+}
+
+// ReasonsAgainstRemoval returns a human-readable list of reasons why the Security Group cannot be removed. The list is
+// empty if the Security Group can be removed
+func (u *SecurityGroupDetails) ReasonsAgainstRemoval() []string {
+	reasons := make([]string, 0)
+	if !u.CanBeRemoved() {
+		if u.Default {
+			reasons = append(reasons, fmt.Sprintf("Security Group is Default in VPC %s", u.VpcId))
+		}
+		if len(u.UsedBy) > 0 {
+			reasons = append(reasons, "Security Group is used by an Elastic Network Interface (ENI)")
+		}
+		if len(u.RuleReferences) > 0 {
+			reasons = append(reasons, "Security Group is referenced by a Security Group Rule")
+		}
+		if len(u.ConfigurationReferences) > 0 {
+			reasons = append(reasons, "Security Group is referenced by a launch template, an Auto Scaling "+
+				"configuration, an ECS configuration or a Lambda function")
+		}
+		if len(u.UncheckedConfigurations) > 0 {
+			reasons = append(reasons, fmt.Sprintf("Security Group might be referenced by configurations which "+
+				"could not be checked (%s)", strings.Join(u.UncheckedConfigurations, "; ")))
+		}
+		if u.IsProtected() {
+			reasons = append(reasons, fmt.Sprintf("Security Group is protected by %s", u.ProtectedBy))
+		}
+	}
+	return reasons
 }
 
 type NetworkInterfaceDetails struct {
-	Id                          string
-	Description                 *string
-	Type                        string
-	ManagedByAWS                bool
-	Status                      string
-	PrivateIPAddress            string
-	SecondaryPrivateIPAddresses []string
-	EC2Attachment               *Ec2Attachment
-	LambdaAttachment            *LambdaAttachment
-	ECSAttachment               *EcsAttachment
-	ELBAttachment               *ElbAttachment
-	VPCEAttachment              *VpceAttachment
-	RDSAttachments              []RdsAttachment
-	SecurityGroupIdentifiers    []SecurityGroupIdentifier
+	Id                          string                    `json:"id"`
+	AccountId                   string                    `json:"accountId"`
+	Region                      string                    `json:"region"`
+	Description                 *string                   `json:"description,omitempty"`
+	Type                        string                    `json:"type"`
+	ManagedByAWS                bool                      `json:"managedByAws"`
+	Status                      string                    `json:"status"`
+	VpcId                       string                    `json:"vpcId"`
+	SubnetId                    string                    `json:"subnetId"`
+	Tags                        map[string]string         `json:"tags"`
+	ProtectedBy                 string                    `json:"protectedBy,omitempty"`
+	PrivateIPAddress            string                    `json:"privateIpAddress"`
+	SecondaryPrivateIPAddresses []string                  `json:"secondaryPrivateIpAddresses"`
+	EC2Attachment               *Ec2Attachment            `json:"ec2Attachment,omitempty"`
+	LambdaAttachment            *LambdaAttachment         `json:"lambdaAttachment,omitempty"`
+	ECSAttachment               *EcsAttachment            `json:"ecsAttachment,omitempty"`
+	ELBAttachment               *ElbAttachment            `json:"elbAttachment,omitempty"`
+	VPCEAttachment              *VpceAttachment           `json:"vpceAttachment,omitempty"`
+	EFSAttachment               *EfsAttachment            `json:"efsAttachment,omitempty"`
+	NATAttachment               *NatGatewayAttachment     `json:"natGatewayAttachment,omitempty"`
+	TGWAttachment               *TransitGatewayAttachment `json:"transitGatewayAttachment,omitempty"`
+	ElastiCacheAttachment       *ElastiCacheAttachment    `json:"elastiCacheAttachment,omitempty"`
+	EKSAttachment               *EksAttachment            `json:"eksAttachment,omitempty"`
+	RDSAttachments              []RdsAttachment           `json:"rdsAttachments,omitempty"`
+	OtherAttachments            []GenericAttachment       `json:"otherAttachments,omitempty"`
+	UnresolvedAttachments       []UnresolvedAttachment    `json:"unresolvedAttachments,omitempty"`
+	SecurityGroupIdentifiers    []SecurityGroupIdentifier `json:"securityGroups"`
 }
 
 func (eni *NetworkInterfaceDetails) IsInUse() bool {
 	return eni.Status == "in-use"
 }
 
+// IsProtected returns true if the Network Interface is protected from removal by a protection tag or a keep-list
+func (eni *NetworkInterfaceDetails) IsProtected() bool {
+	return eni.ProtectedBy != ""
+}
+
+// CanBeRemoved returns true if the Network Interface can be removed, meaning it is not in use and it is not protected
+func (eni *NetworkInterfaceDetails) CanBeRemoved() bool {
+	return !eni.IsInUse() && !eni.IsProtected()
+}
+
+// ReasonsAgainstRemoval returns a human-readable list of reasons why the Network Interface cannot be removed. The list
+// is empty if the Network Interface can be removed
+func (eni *NetworkInterfaceDetails) ReasonsAgainstRemoval() []string {
+	reasons := make([]string, 0)
+	if !eni.CanBeRemoved() {
+		if eni.IsInUse() {
+			reasons = append(reasons, "Network Interface is attached to a resource (status: in-use)")
+		}
+		if eni.IsProtected() {
+			reasons = append(reasons, fmt.Sprintf("Network Interface is protected by %s", eni.ProtectedBy))
+		}
+	}
+	return reasons
+}
+
+// Owner is a resource owning a Network Interface. The ID is unique for the kind of the resource in its account and
+// region. The label names the resource, while the details describe it together with its state and the resources it
+// belongs to
+type Owner struct {
+	Kind         string
+	Id           string
+	Label        string
+	Details      string
+	IsRemoved    bool
+	IsUnresolved bool
+}
+
+// Attachments returns the attachments recorded on the Network Interface, in the order of the built-in resolvers
+// followed by the attachments of the other resolvers
+func (eni *NetworkInterfaceDetails) Attachments() []Attachment {
+	attachments := make([]Attachment, 0)
+	if eni.EC2Attachment != nil {
+		attachments = append(attachments, eni.EC2Attachment)
+	}
+	if eni.LambdaAttachment != nil {
+		attachments = append(attachments, eni.LambdaAttachment)
+	}
+	if eni.ECSAttachment != nil {
+		attachments = append(attachments, eni.ECSAttachment)
+	}
+	if eni.ELBAttachment != nil {
+		attachments = append(attachments, eni.ELBAttachment)
+	}
+	if eni.VPCEAttachment != nil {
+		attachments = append(attachments, eni.VPCEAttachment)
+	}
+	if eni.EFSAttachment != nil {
+		attachments = append(attachments, eni.EFSAttachment)
+	}
+	if eni.NATAttachment != nil {
+		attachments = append(attachments, eni.NATAttachment)
+	}
+	if eni.TGWAttachment != nil {
+		attachments = append(attachments, eni.TGWAttachment)
+	}
+	if eni.ElastiCacheAttachment != nil {
+		attachments = append(attachments, eni.ElastiCacheAttachment)
+	}
+	if eni.EKSAttachment != nil {
+		attachments = append(attachments, eni.EKSAttachment)
+	}
+	if len(eni.RDSAttachments) > 0 {
+		attachments = append(attachments, RdsAttachments(eni.RDSAttachments))
+	}
+	for i := range eni.OtherAttachments {
+		attachments = append(attachments, &eni.OtherAttachments[i])
+	}
+	for i := range eni.UnresolvedAttachments {
+		attachments = append(attachments, &eni.UnresolvedAttachments[i])
+	}
+	return attachments
+}
+
+// Owners returns the resources owning the Network Interface according to its attachments. A resource which was removed
+// and cannot be identified anymore, or which cannot be resolved, gets an ID derived from the Network Interface
+func (eni *NetworkInterfaceDetails) Owners() []Owner {
+	owners := make([]Owner, 0)
+	for _, attachment := range eni.Attachments() {
+		for _, owner := range attachment.Owners() {
+			switch {
+			case owner.IsUnresolved:
+				owner.Id = "unresolved-" + eni.Id
+				owner.Label = fmt.Sprintf("%s (unresolved)", owner.Label)
+			case owner.Id == "":
+				owner.Id = "removed-" + eni.Id
+			}
+			if owner.IsRemoved {
+				owner.Label = fmt.Sprintf("%s (removed)", owner.Label)
+			}
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// Attachment is implemented by the types describing the resource which is using a Network Interface
+type Attachment interface {
+	// AttachTo records the attachment on the network interface details
+	AttachTo(eni *NetworkInterfaceDetails)
+	// Owners returns the resources described by the attachment. The ID of a resource which was removed and cannot be
+	// identified anymore, or which cannot be resolved, may be empty
+	Owners() []Owner
+}
+
 type Ec2Attachment struct {
-	InstanceId string
+	InstanceId string `json:"instanceId"`
+}
+
+func (a *Ec2Attachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.EC2Attachment = a
+}
+
+func (a *Ec2Attachment) Owners() []Owner {
+	label := fmt.Sprintf("EC2 instance %s", a.InstanceId)
+	return []Owner{{Kind: "ec2", Id: a.InstanceId, Label: label, Details: label}}
 }
 
 type LambdaAttachment struct {
-	IsRemoved bool
-	Name      string
-	Arn       *string
+	IsRemoved bool    `json:"isRemoved"`
+	Name      string  `json:"name"`
+	Arn       *string `json:"arn,omitempty"`
+}
+
+func (a *LambdaAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.LambdaAttachment = a
+}
+
+func (a *LambdaAttachment) Owners() []Owner {
+	label := fmt.Sprintf("Lambda function %s", a.Name)
+	details := label
+	if a.Arn != nil {
+		details = fmt.Sprintf("%s (%s)", label, *a.Arn)
+	}
+	return []Owner{{Kind: "lambda", Id: a.Name, Label: label, Details: details, IsRemoved: a.IsRemoved}}
 }
 
 type EcsAttachment struct {
-	IsRemoved     bool
-	ClusterArn    *string
-	ContainerName *string
-	TaskArn       *string
+	IsRemoved     bool    `json:"isRemoved"`
+	ClusterArn    *string `json:"clusterArn,omitempty"`
+	ContainerName *string `json:"containerName,omitempty"`
+	TaskArn       *string `json:"taskArn,omitempty"`
+}
+
+func (a *EcsAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.ECSAttachment = a
+}
+
+func (a *EcsAttachment) Owners() []Owner {
+	taskArn := aws.ToString(a.TaskArn)
+	return []Owner{{
+		Kind:  "ecs",
+		Id:    taskArn,
+		Label: fmt.Sprintf("ECS task %s", taskArn),
+		Details: fmt.Sprintf("ECS task %s of cluster %s (container %s)", textOrUnknown(a.TaskArn),
+			textOrUnknown(a.ClusterArn), textOrUnknown(a.ContainerName)),
+		IsRemoved: a.IsRemoved,
+	}}
 }
 
 type ElbAttachment struct {
-	IsRemoved bool
-	Name      string
-	Arn       *string
+	IsRemoved bool    `json:"isRemoved"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Arn       *string `json:"arn,omitempty"`
+}
+
+func (a *ElbAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.ELBAttachment = a
+}
+
+func (a *ElbAttachment) Owners() []Owner {
+	details := fmt.Sprintf("%s %s", getELBTypeText(a.Type), a.Name)
+	if a.Arn != nil {
+		details = fmt.Sprintf("%s (%s)", details, *a.Arn)
+	}
+	return []Owner{{
+		Kind:      "elb",
+		Id:        a.Name,
+		Label:     fmt.Sprintf("%s load balancer %s", a.Type, a.Name),
+		Details:   details,
+		IsRemoved: a.IsRemoved,
+	}}
+}
+
+// Get the human-readable name of a load balancer type
+func getELBTypeText(elbType string) string {
+	switch elbType {
+	case "application":
+		return "Application Load Balancer"
+	case "network":
+		return "Network Load Balancer"
+	case "gateway":
+		return "Gateway Load Balancer"
+	case "classic":
+		return "Classic Load Balancer"
+	default:
+		return "Load Balancer"
+	}
 }
 
 type VpceAttachment struct {
-	IsRemoved   bool
-	Id          *string
-	ServiceName *string
+	IsRemoved   bool    `json:"isRemoved"`
+	Id          *string `json:"id,omitempty"`
+	ServiceName *string `json:"serviceName,omitempty"`
+}
+
+func (a *VpceAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.VPCEAttachment = a
+}
+
+func (a *VpceAttachment) Owners() []Owner {
+	label := fmt.Sprintf("VPC endpoint %s (%s)", aws.ToString(a.Id), aws.ToString(a.ServiceName))
+	return []Owner{{Kind: "vpce", Id: aws.ToString(a.Id), Label: label, Details: label, IsRemoved: a.IsRemoved}}
+}
+
+type EfsAttachment struct {
+	IsRemoved     bool    `json:"isRemoved"`
+	FileSystemId  string  `json:"fileSystemId"`
+	MountTargetId string  `json:"mountTargetId"`
+	Name          *string `json:"name,omitempty"`
+}
+
+func (a *EfsAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.EFSAttachment = a
+}
+
+func (a *EfsAttachment) Owners() []Owner {
+	fileSystem := a.FileSystemId
+	if a.Name != nil && *a.Name != "" {
+		fileSystem = fmt.Sprintf("%s (%s)", *a.Name, a.FileSystemId)
+	}
+	return []Owner{{
+		Kind:      "efs",
+		Id:        a.FileSystemId,
+		Label:     fmt.Sprintf("EFS file system %s", fileSystem),
+		Details:   fmt.Sprintf("EFS mount target %s of %s", a.MountTargetId, fileSystem),
+		IsRemoved: a.IsRemoved,
+	}}
+}
+
+type NatGatewayAttachment struct {
+	IsRemoved bool   `json:"isRemoved"`
+	Id        string `json:"id"`
+	State     string `json:"state,omitempty"`
+}
+
+func (a *NatGatewayAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.NATAttachment = a
+}
+
+func (a *NatGatewayAttachment) Owners() []Owner {
+	label := fmt.Sprintf("NAT Gateway %s", a.Id)
+	details := label
+	if a.State != "" {
+		details = fmt.Sprintf("%s (%s)", label, a.State)
+	}
+	return []Owner{{Kind: "nat", Id: a.Id, Label: label, Details: details, IsRemoved: a.IsRemoved}}
+}
+
+type TransitGatewayAttachment struct {
+	IsRemoved        bool    `json:"isRemoved"`
+	AttachmentId     string  `json:"attachmentId"`
+	TransitGatewayId *string `json:"transitGatewayId,omitempty"`
+	State            string  `json:"state,omitempty"`
+}
+
+func (a *TransitGatewayAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.TGWAttachment = a
+}
+
+func (a *TransitGatewayAttachment) Owners() []Owner {
+	label := fmt.Sprintf("Transit Gateway attachment %s", a.AttachmentId)
+	details := label
+	if a.State != "" {
+		details = fmt.Sprintf("%s (%s)", details, a.State)
+	}
+	if a.TransitGatewayId != nil {
+		details = fmt.Sprintf("%s of %s", details, *a.TransitGatewayId)
+	}
+	return []Owner{{Kind: "tgw", Id: a.AttachmentId, Label: label, Details: details, IsRemoved: a.IsRemoved}}
+}
+
+// ElastiCacheAttachment describes the cache cluster, and the replication group if the cluster is a member of one, which
+// owns a Network Interface. IsUnresolved is set if no cache cluster could be matched to the Network Interface, but it
+// cannot be proven that its owner was removed, in which case the identifier comes from the description of the Network
+// Interface
+type ElastiCacheAttachment struct {
+	IsRemoved          bool    `json:"isRemoved"`
+	IsUnresolved       bool    `json:"isUnresolved,omitempty"`
+	CacheClusterId     string  `json:"cacheClusterId"`
+	ReplicationGroupId *string `json:"replicationGroupId,omitempty"`
+	Engine             *string `json:"engine,omitempty"`
+}
+
+func (a *ElastiCacheAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.ElastiCacheAttachment = a
+}
+
+func (a *ElastiCacheAttachment) Owners() []Owner {
+	label := fmt.Sprintf("ElastiCache cluster %s", a.CacheClusterId)
+	details := label
+	if a.Engine != nil {
+		details = fmt.Sprintf("%s (%s)", details, *a.Engine)
+	}
+	if a.ReplicationGroupId != nil {
+		details = fmt.Sprintf("%s of replication group %s", details, *a.ReplicationGroupId)
+	}
+
+	if a.IsUnresolved {
+		return []Owner{{Kind: "elasticache", Label: label, Details: details, IsUnresolved: true}}
+	}
+	return []Owner{{Kind: "elasticache", Id: a.CacheClusterId, Label: label, Details: details, IsRemoved: a.IsRemoved}}
+}
+
+const (
+	EksControlPlane = "control-plane"
+	EksNode         = "node"
+	EksTrunk        = "trunk"
+	EksBranch       = "branch"
+)
+
+// EksAttachment describes the EKS resource which owns a Network Interface. Kind is one of EksControlPlane, EksNode,
+// EksTrunk or EksBranch. The resource is removed if the cluster does not exist anymore for the control plane, if the
+// node instance was terminated for the node and trunk interfaces, or if the trunk interface does not exist anymore for
+// a pod branch interface. These are the interfaces leaked by the VPC CNI.
+type EksAttachment struct {
+	IsRemoved        bool    `json:"isRemoved"`
+	Kind             string  `json:"kind"`
+	ClusterName      *string `json:"clusterName,omitempty"`
+	InstanceId       *string `json:"instanceId,omitempty"`
+	TrunkInterfaceId *string `json:"trunkInterfaceId,omitempty"`
+}
+
+func (a *EksAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.EKSAttachment = a
+}
+
+func (a *EksAttachment) Owners() []Owner {
+	cluster := "unknown cluster"
+	if a.ClusterName != nil {
+		cluster = *a.ClusterName
+	}
+
+	var details string
+	switch {
+	case a.InstanceId != nil:
+		details = fmt.Sprintf("EKS %s %s of %s", getEKSKindText(a.Kind), *a.InstanceId, cluster)
+	case a.TrunkInterfaceId != nil:
+		details = fmt.Sprintf("EKS %s of trunk %s of %s", getEKSKindText(a.Kind), *a.TrunkInterfaceId, cluster)
+	default:
+		details = fmt.Sprintf("EKS %s %s", getEKSKindText(a.Kind), cluster)
+	}
+
+	owner := Owner{Details: details, IsRemoved: a.IsRemoved}
+	switch a.Kind {
+	case EksControlPlane:
+		clusterName := aws.ToString(a.ClusterName)
+		owner.Kind, owner.Id, owner.Label = "eks", clusterName, fmt.Sprintf("EKS cluster %s", clusterName)
+	case EksBranch:
+		trunkId := aws.ToString(a.TrunkInterfaceId)
+		owner.Kind, owner.Id, owner.Label = "eks-trunk", trunkId, fmt.Sprintf("EKS trunk interface %s", trunkId)
+	default:
+		instanceId := aws.ToString(a.InstanceId)
+		owner.Kind, owner.Id, owner.Label = "ec2", instanceId, fmt.Sprintf("EKS node %s", instanceId)
+	}
+	return []Owner{owner}
+}
+
+// Get the human-readable name of the kind of an EKS attachment
+func getEKSKindText(kind string) string {
+	switch kind {
+	case EksControlPlane:
+		return "cluster control plane"
+	case EksNode:
+		return "node"
+	case EksTrunk:
+		return "node trunk interface"
+	case EksBranch:
+		return "pod branch interface"
+	default:
+		return "resource"
+	}
 }
 
+// RdsAttachment describes the DB instance or the DB proxy which owns a Network Interface. Type is either "instance" or
+// "proxy". IsUnresolved is set if no DB instance or DB proxy could be matched to the Network Interface, in which case
+// the identifier is empty unless it is known from the description of the Network Interface. An unresolved owner might
+// still exist, so the Network Interface is not considered leaked.
 type RdsAttachment struct {
-	IsRemoved  bool
-	Identifier string
+	IsRemoved         bool    `json:"isRemoved"`
+	IsUnresolved      bool    `json:"isUnresolved,omitempty"`
+	Identifier        string  `json:"identifier"`
+	Type              string  `json:"type"`
+	Engine            *string `json:"engine,omitempty"`
+	ClusterIdentifier *string `json:"clusterIdentifier,omitempty"`
+}
+
+// RdsAttachments is the list of RDS resources which might be using a Network Interface. It contains more than one
+// resource only if the Network Interface could not be matched precisely
+type RdsAttachments []RdsAttachment
+
+func (a RdsAttachments) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.RDSAttachments = a
+}
+
+func (a RdsAttachments) Owners() []Owner {
+	owners := make([]Owner, 0, len(a))
+	for _, attachment := range a {
+		identifier := attachment.Identifier
+		if identifier == "" {
+			identifier = "unknown"
+		}
+		details := fmt.Sprintf("RDS DB %s %s", attachment.Type, identifier)
+		if attachment.Engine != nil {
+			details = fmt.Sprintf("%s (%s)", details, *attachment.Engine)
+		}
+		if attachment.ClusterIdentifier != nil {
+			details = fmt.Sprintf("%s of DB cluster %s", details, *attachment.ClusterIdentifier)
+		}
+		if len(a) > 1 {
+			details = fmt.Sprintf("%s, might be inaccurate", details)
+		}
+
+		owner := Owner{
+			Kind:    "rds-" + attachment.Type,
+			Label:   fmt.Sprintf("RDS DB %s %s", attachment.Type, attachment.Identifier),
+			Details: details,
+		}
+		if attachment.IsUnresolved {
+			owner.IsUnresolved = true
+		} else {
+			owner.Id, owner.IsRemoved = attachment.Identifier, attachment.IsRemoved
+		}
+		owners = append(owners, owner)
+	}
+	return owners
+}
+
+// GenericAttachment can be used by attachment resolvers for resources which do not have a dedicated attachment type
+type GenericAttachment struct {
+	IsRemoved bool   `json:"isRemoved"`
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+}
+
+func (a *GenericAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.OtherAttachments = append(eni.OtherAttachments, *a)
+}
+
+func (a *GenericAttachment) Owners() []Owner {
+	name := a.Id
+	if a.Name != "" {
+		name = fmt.Sprintf("%s (%s)", a.Name, a.Id)
+	}
+	label := fmt.Sprintf("%s %s", a.Type, name)
+	return []Owner{{Kind: a.Type, Id: a.Id, Label: label, Details: label, IsRemoved: a.IsRemoved}}
+}
+
+// UnresolvedAttachment records a resolver which failed to check if its resources are using a Network Interface, so the
+// Network Interface might be used by a resource which is not reported
+type UnresolvedAttachment struct {
+	Resolver string `json:"resolver"`
+	Error    string `json:"error"`
+}
+
+func (a *UnresolvedAttachment) AttachTo(eni *NetworkInterfaceDetails) {
+	eni.UnresolvedAttachments = append(eni.UnresolvedAttachments, *a)
+}
+
+// Owners returns no resource, since the owner of the Network Interface is not known
+func (a *UnresolvedAttachment) Owners() []Owner {
+	return nil
+}
+
+// Get the text of an optional value, or "unknown" if the value is not known
+func textOrUnknown(value *string) string {
+	if value == nil {
+		return "unknown"
+	}
+	return *value
 }
 
 type SecurityGroupIdentifier struct {
-	Name *string
-	Id   string
+	Name *string `json:"name,omitempty"`
+	Id   string  `json:"id"`
+}
+
+// SecurityGroupRule is an inbound or outbound rule of a Security Group. The source/destination of a rule is exactly one
+// of the CIDR blocks, the prefix list or the referenced Security Group
+type SecurityGroupRule struct {
+	Id                     string  `json:"id"`
+	GroupId                string  `json:"groupId"`
+	IsEgress               bool    `json:"isEgress"`
+	Protocol               string  `json:"protocol"`
+	FromPort               int32   `json:"fromPort"`
+	ToPort                 int32   `json:"toPort"`
+	CidrIpv4               *string `json:"cidrIpv4,omitempty"`
+	CidrIpv6               *string `json:"cidrIpv6,omitempty"`
+	PrefixListId           *string `json:"prefixListId,omitempty"`
+	ReferencedGroupId      *string `json:"referencedGroupId,omitempty"`
+	ReferencedGroupOwnerId *string `json:"referencedGroupOwnerId,omitempty"`
+	Description            *string `json:"description,omitempty"`
+}
+
+// RevokedRule is a Security Group Rule revoked by a cascading removal, so that the Security Group it references could
+// be removed. The rule can be restored by authorizing it again in its Security Group
+type RevokedRule struct {
+	AccountId string            `json:"accountId"`
+	Region    string            `json:"region"`
+	Rule      SecurityGroupRule `json:"rule"`
+}
+
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// AuditFinding is a risky rule of a Security Group, or a risky Security Group if RuleId is empty. The affected Network
+// Interfaces and resources are the ones using the Security Group, so findings of groups in use can be fixed first
+type AuditFinding struct {
+	Check             string   `json:"check"`
+	Severity          string   `json:"severity"`
+	Message           string   `json:"message"`
+	SecurityGroupId   string   `json:"securityGroupId"`
+	SecurityGroupName string   `json:"securityGroupName"`
+	VpcId             string   `json:"vpcId"`
+	AccountId         string   `json:"accountId"`
+	Region            string   `json:"region"`
+	RuleId            string   `json:"ruleId,omitempty"`
+	InUse             bool     `json:"inUse"`
+	NetworkInterfaces []string `json:"networkInterfaces"`
+	Resources         []string `json:"resources"`
+}
+
+// DedupeMember is a Security Group compared by the dedupe analysis, together with the Network Interfaces using it
+type DedupeMember struct {
+	Id        string   `json:"id"`
+	Name      string   `json:"name"`
+	RuleCount int      `json:"ruleCount"`
+	UsedBy    []string `json:"usedBy"`
+}
+
+// DuplicateSecurityGroups are Security Groups of the same VPC which allow the same traffic
+type DuplicateSecurityGroups struct {
+	AccountId      string         `json:"accountId"`
+	Region         string         `json:"region"`
+	VpcId          string         `json:"vpcId"`
+	SecurityGroups []DedupeMember `json:"securityGroups"`
+}
+
+// SubsetSecurityGroups are Security Groups of the same VPC allowing a strict subset of the traffic allowed by other
+// Security Groups. Each side lists the Security Groups which are duplicates of each other
+type SubsetSecurityGroups struct {
+	AccountId string         `json:"accountId"`
+	Region    string         `json:"region"`
+	VpcId     string         `json:"vpcId"`
+	Subset    []DedupeMember `json:"subset"`
+	Superset  []DedupeMember `json:"superset"`
+}
+
+// DedupeReport lists the Security Groups which are candidates for consolidation
+type DedupeReport struct {
+	Duplicates []DuplicateSecurityGroups `json:"duplicates"`
+	Subsets    []SubsetSecurityGroups    `json:"subsets"`
+}
+
+// RuleFinding is a rule of a Security Group which can be removed: either it allows only traffic which is already
+// allowed by another rule of the same Security Group, or it references a Security Group which does not exist anymore.
+// RuleCount is the number of rules of the Security Group, which counts against the rules quota
+type RuleFinding struct {
+	Check             string            `json:"check"`
+	Message           string            `json:"message"`
+	SecurityGroupId   string            `json:"securityGroupId"`
+	SecurityGroupName string            `json:"securityGroupName"`
+	VpcId             string            `json:"vpcId"`
+	AccountId         string            `json:"accountId"`
+	Region            string            `json:"region"`
+	RuleCount         int               `json:"ruleCount"`
+	Rule              SecurityGroupRule `json:"rule"`
+	CoveredBy         string            `json:"coveredBy,omitempty"`
 }
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	eni := NetworkInterfaceDetails{
+		Id:               "eni-1",
+		EC2Attachment:    &Ec2Attachment{InstanceId: "i-1"},
+		LambdaAttachment: &LambdaAttachment{IsRemoved: true},
+		RDSAttachments: []RdsAttachment{
+			{Identifier: "orders", Type: "instance", Engine: aws.String("postgres")},
+			{IsUnresolved: true, Type: "proxy"},
+		},
+		OtherAttachments:      []GenericAttachment{{Type: "mq", Id: "b-1", Name: "broker"}},
+		UnresolvedAttachments: []UnresolvedAttachment{{Resolver: "efs", Error: "access denied"}},
+	}
+
+	require.Equal(t, []Owner{
+		{Kind: "ec2", Id: "i-1", Label: "EC2 instance i-1", Details: "EC2 instance i-1"},
+		{Kind: "lambda", Id: "removed-eni-1", Label: "Lambda function  (removed)", Details: "Lambda function ",
+			IsRemoved: true},
+		{Kind: "rds-instance", Id: "orders", Label: "RDS DB instance orders",
+			Details: "RDS DB instance orders (postgres), might be inaccurate"},
+		{Kind: "rds-proxy", Id: "unresolved-eni-1", Label: "RDS DB proxy  (unresolved)",
+			Details: "RDS DB proxy unknown, might be inaccurate", IsUnresolved: true},
+		{Kind: "mq", Id: "b-1", Label: "mq broker (b-1)", Details: "mq broker (b-1)"},
+	}, eni.Owners())
+}
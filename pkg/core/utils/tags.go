@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2Types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// TagsToMap converts the tags returned by the EC2 API to a map of tag keys to tag values
+func TagsToMap(tags []ec2Types.Tag) map[string]string {
+	tagMap := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagMap[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tagMap
+}